@@ -0,0 +1,458 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/nemirlev/zenexport/internal/config"
+	"github.com/nemirlev/zenexport/internal/db"
+	"github.com/nemirlev/zenexport/internal/db/model"
+	"github.com/nemirlev/zenexport/internal/entity"
+	"github.com/nemirlev/zenexport/internal/export"
+	"github.com/nemirlev/zenexport/internal/ledger"
+	"github.com/nemirlev/zenexport/internal/logger"
+	"github.com/nemirlev/zenexport/internal/notify"
+	"github.com/nemirlev/zenexport/internal/tracing"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// tracingShutdownTimeout время на отправку оставшихся спанов при завершении.
+const tracingShutdownTimeout = 5 * time.Second
+
+// command описывает команду zenexport.
+type command struct {
+	name  string
+	usage string
+	run   func(ctx context.Context, args []string) error
+}
+
+var commands = []command{
+	{name: "sync", usage: "Fetch data from ZenMoney once and save it to the database", run: runSyncCommand},
+	{name: "backfill", usage: "Reload budgets, reminder markers and transactions for -since/-until in place", run: runBackfillCommand},
+	{name: "daemon", usage: "Sync data every -interval minutes until stopped", run: runDaemonCommand},
+	{name: "migrate", usage: "Apply database migrations", run: runMigrateCommand},
+	{name: "status", usage: "Show the last run of each profile and row counts", run: runStatusCommand},
+	{name: "verify", usage: "Compare row counts and account balances in the database with ZenMoney", run: runVerifyCommand},
+	{name: "export", usage: "Export ZenMoney data to files without a database", run: runExportCommand},
+}
+
+// printUsage выводит список команд.
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: zenexport <command> [flags]")
+	fmt.Fprintln(w, "\nCommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-9s %s\n", cmd.name, cmd.usage)
+	}
+	fmt.Fprintln(w, "\nRun 'zenexport <command> -h' for command flags.")
+}
+
+// newFlagSet создает набор флагов команды со справкой.
+func newFlagSet(name string, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: zenexport %s [flags]\n\n%s\n\nFlags:\n", name, usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// app содержит зависимости, общие для команд.
+type app struct {
+	cfg      *config.Config
+	log      logger.Log
+	store    db.DataStore
+	clients  []profileClient
+	notifier *notify.Dispatcher
+
+	shutdownTracing func(ctx context.Context) error
+}
+
+// newApp создает логгер по настройкам LOG_LEVEL/LOG_FORMAT/LOG_FILE, клиентов ZenMoney для всех профилей,
+// получателей уведомлений и, если нужно, открывает соединение с базой данных.
+func newApp(ctx context.Context, cfg *config.Config, withStore bool) (*app, error) {
+	appLog, err := logger.NewWithOptions(logger.Options{
+		Level:  cfg.LogLevel,
+		Format: cfg.LogFormat,
+		File:   cfg.LogFile,
+	})
+	if err != nil {
+		return nil, err
+	}
+	appLog.Debug("config loaded", "config", cfg)
+
+	a := &app{cfg: cfg, log: appLog, notifier: newNotifier(cfg)}
+	a.shutdownTracing, err = tracing.Setup(ctx, tracing.Options{
+		Exporter: cfg.TracingExporter,
+		Endpoint: cfg.TracingEndpoint,
+	})
+	if err != nil {
+		a.close()
+		return nil, err
+	}
+	a.clients, err = createClients(cfg.Profiles())
+	if err != nil {
+		a.close()
+		return nil, fmt.Errorf("failed to create client: %w", err)
+	}
+	if !withStore {
+		return a, nil
+	}
+
+	a.store, err = db.NewDataStore(cfg, appLog)
+	if err != nil {
+		a.close()
+		return nil, fmt.Errorf("failed to setup database: %w", err)
+	}
+	if err := a.store.Open(ctx); err != nil {
+		a.store = nil
+		a.close()
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	return a, nil
+}
+
+// newNotifier создает диспетчер уведомлений по настройкам NOTIFY_*. Без webhook и команды уведомления не отправляются.
+func newNotifier(cfg *config.Config) *notify.Dispatcher {
+	var notifiers []notify.Notifier
+	if cfg.NotifyWebhookURL != "" {
+		notifiers = append(notifiers, notify.Webhook{URL: cfg.NotifyWebhookURL})
+	}
+	if cfg.NotifyCommand != "" {
+		notifiers = append(notifiers, notify.Command{Command: cfg.NotifyCommand})
+	}
+	return notify.NewDispatcher(cfg.NotifyOn, cfg.NotifyThreshold, notifiers...)
+}
+
+// close закрывает соединение с базой данных, отправляет оставшиеся спаны и закрывает файл логов.
+func (a *app) close() {
+	if a.store != nil {
+		if err := a.store.Close(); err != nil {
+			a.log.WithError(err, "failed to close database")
+		}
+	}
+	if a.shutdownTracing != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), tracingShutdownTimeout)
+		defer cancel()
+		if err := a.shutdownTracing(ctx); err != nil {
+			a.log.WithError(err, "failed to flush traces")
+		}
+	}
+	if err := a.log.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to close log file: %v\n", err)
+	}
+}
+
+// loadCommand разбирает флаги команды и загружает конфигурацию.
+func loadCommand(fs *flag.FlagSet, args []string, opts ...config.Option) (*config.Config, error) {
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return nil, fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	return config.Load(fs, opts...)
+}
+
+func runSyncCommand(ctx context.Context, args []string) error {
+	fs := newFlagSet("sync", "Fetch data of every profile from ZenMoney once and save it to the database.")
+	config.DefineFlags(fs)
+	config.DefineSyncFlags(fs)
+
+	cfg, err := loadCommand(fs, args)
+	if err != nil {
+		return err
+	}
+
+	a, err := newApp(ctx, cfg, true)
+	if err != nil {
+		return err
+	}
+	defer a.close()
+
+	if cfg.DryRun {
+		return runDryRun(ctx, a.log, cfg, a.clients, a.store)
+	}
+	if cfg.Migrate {
+		if err := a.store.Migrate(ctx); err != nil {
+			return err
+		}
+	}
+	return runAllProfiles(ctx, a.log, a.clients, a.store, a.notifier)
+}
+
+func runBackfillCommand(ctx context.Context, args []string) error {
+	fs := newFlagSet("backfill", "Reload budgets, reminder markers and transactions dated between -since and -until.\n"+
+		"Other months and other entities are left untouched.")
+	config.DefineFlags(fs)
+	config.DefineSyncFlags(fs)
+
+	cfg, err := loadCommand(fs, args)
+	if err != nil {
+		return err
+	}
+	period, err := entity.ParsePeriod(cfg.Since, cfg.Until)
+	if err != nil {
+		return err
+	}
+	if !period.Bounded() {
+		fs.Usage()
+		return errors.New("backfill requires both -since and -until")
+	}
+
+	// Перезаписываются только включенные сущности с датой
+	filter, err := entity.NewFilter(cfg.IncludeEntities, cfg.ExcludeEntities)
+	if err != nil {
+		return err
+	}
+	cfg.IncludeEntities = nil
+	for _, name := range entity.Dated {
+		if filter.Enabled(name) {
+			cfg.IncludeEntities = append(cfg.IncludeEntities, name)
+		}
+	}
+	if len(cfg.IncludeEntities) == 0 {
+		return errors.New("backfill has nothing to do: budget, reminder_marker and transaction are all excluded")
+	}
+
+	a, err := newApp(ctx, cfg, true)
+	if err != nil {
+		return err
+	}
+	defer a.close()
+
+	if cfg.DryRun {
+		return runDryRun(ctx, a.log, cfg, a.clients, a.store)
+	}
+	if cfg.Migrate {
+		if err := a.store.Migrate(ctx); err != nil {
+			return err
+		}
+	}
+	return runAllProfiles(ctx, a.log, a.clients, a.store, a.notifier)
+}
+
+func runDaemonCommand(ctx context.Context, args []string) error {
+	fs := newFlagSet("daemon", "Sync every profile each -interval minutes until SIGINT or SIGTERM is received.")
+	config.DefineFlags(fs)
+	config.DefineSyncFlags(fs)
+	config.DefineScheduleFlags(fs)
+
+	cfg, err := loadCommand(fs, args, config.AsDaemon())
+	if err != nil {
+		return err
+	}
+
+	a, err := newApp(ctx, cfg, true)
+	if err != nil {
+		return err
+	}
+	defer a.close()
+
+	if cfg.Migrate {
+		if err := a.store.Migrate(ctx); err != nil {
+			return err
+		}
+	}
+	runDaemon(ctx, a.log, a.clients, a.store, a.notifier, time.Duration(cfg.Interval)*time.Minute)
+	return nil
+}
+
+func runMigrateCommand(ctx context.Context, args []string) error {
+	fs := newFlagSet("migrate", "Apply embedded database migrations. Migrations of disabled entities are skipped.")
+	config.DefineFlags(fs)
+
+	cfg, err := loadCommand(fs, args, config.WithoutToken())
+	if err != nil {
+		return err
+	}
+
+	a, err := newApp(ctx, cfg, true)
+	if err != nil {
+		return err
+	}
+	defer a.close()
+
+	return a.store.Migrate(ctx)
+}
+
+func runStatusCommand(ctx context.Context, args []string) error {
+	fs := newFlagSet("status", "Show the last sync run of each profile and row counts of exported tables.")
+	config.DefineFlags(fs)
+
+	cfg, err := loadCommand(fs, args, config.WithoutToken())
+	if err != nil {
+		return err
+	}
+
+	a, err := newApp(ctx, cfg, true)
+	if err != nil {
+		return err
+	}
+	defer a.close()
+
+	status, err := a.store.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROFILE\tLAST RUN\tDURATION\tSTATUS\tERROR")
+	for _, run := range status.Runs {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", run.Profile, run.StartedAt.Format(time.DateTime),
+			run.Duration().Round(time.Millisecond), run.Status, run.Error)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "TABLE\tPROFILE\tROWS")
+	for _, rows := range status.Rows {
+		fmt.Fprintf(w, "%s\t%s\t%d\n", rows.Table, rows.Profile, rows.Rows)
+	}
+	return w.Flush()
+}
+
+func runVerifyCommand(ctx context.Context, args []string) error {
+	fs := newFlagSet("verify", "Fetch data from ZenMoney, compare row counts with the database and check that account balances\n"+
+		"match start balances plus transactions. Balance check results are saved to the reconciliation table.")
+	config.DefineFlags(fs)
+	tolerance := fs.Float64("tolerance", 0.01, "Maximum allowed difference between an account balance and its computed balance")
+
+	cfg, err := loadCommand(fs, args)
+	if err != nil {
+		return err
+	}
+	if *tolerance < 0 {
+		fs.Usage()
+		return errors.New("tolerance must not be negative")
+	}
+
+	a, err := newApp(ctx, cfg, true)
+	if err != nil {
+		return err
+	}
+	defer a.close()
+
+	if err := a.store.Ping(ctx); err != nil {
+		return fmt.Errorf("database is not available: %w", err)
+	}
+
+	status, err := a.store.Status(ctx)
+	if err != nil {
+		return err
+	}
+	stored := make(map[string]uint64, len(status.Rows))
+	for _, rows := range status.Rows {
+		stored[rows.Profile+"/"+rows.Table] = rows.Rows
+	}
+
+	filter, err := entity.NewFilter(cfg.IncludeEntities, cfg.ExcludeEntities)
+	if err != nil {
+		return err
+	}
+	// Остатки сверяются, только если экспортируются счета
+	reconcile := filter.Enabled(entity.Account)
+
+	mismatches, balanceMismatches := 0, 0
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "PROFILE\tTABLE\tZENMONEY\tDATABASE\tRESULT")
+	reconciliations := make(map[string][]model.Reconciliation, len(a.clients))
+	for _, pc := range a.clients {
+		data, err := pc.client.FullSync()
+		if err != nil {
+			return fmt.Errorf("profile %s: %w", pc.label, err)
+		}
+		counts := entity.Counts(&data)
+		for _, name := range entity.All {
+			if !filter.Enabled(name) {
+				continue
+			}
+			result := "ok"
+			if counts[name] != stored[pc.label+"/"+name] {
+				result = "mismatch"
+				mismatches++
+			}
+			fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\n", pc.label, name, counts[name], stored[pc.label+"/"+name], result)
+		}
+		if reconcile {
+			reconciliations[pc.label] = ledger.Reconcile(data.Account, data.Transaction, *tolerance)
+		}
+	}
+
+	if reconcile {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "PROFILE\tACCOUNT\tTITLE\tBALANCE\tCOMPUTED\tDIFFERENCE\tRESULT")
+		for _, pc := range a.clients {
+			for _, r := range reconciliations[pc.label] {
+				if r.Status != model.ReconciliationOK {
+					balanceMismatches++
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%.2f\t%.2f\t%.2f\t%s\n", pc.label, r.Account, r.Title, r.Balance, r.Computed,
+					r.Difference, r.Status)
+			}
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	for _, pc := range a.clients {
+		if !reconcile {
+			break
+		}
+		if err := a.store.SaveReconciliation(ctx, pc.label, reconciliations[pc.label]); err != nil {
+			return fmt.Errorf("profile %s: failed to save reconciliation: %w", pc.label, err)
+		}
+	}
+
+	var problems []string
+	if mismatches > 0 {
+		problems = append(problems, fmt.Sprintf("%d tables differ from ZenMoney", mismatches))
+	}
+	if balanceMismatches > 0 {
+		problems = append(problems, fmt.Sprintf("%d account balances differ from transactions", balanceMismatches))
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("verification failed: %s", strings.Join(problems, ", "))
+	}
+	return nil
+}
+
+func runExportCommand(ctx context.Context, args []string) error {
+	fs := newFlagSet("export", "Fetch data of every profile from ZenMoney and write it to files without a database.")
+	config.DefineFlags(fs)
+	format := fs.String("format", export.FormatJSON, "Output format: "+strings.Join(export.Formats, ", "))
+	output := fs.String("output", ".", "Output directory")
+
+	cfg, err := loadCommand(fs, args, config.WithoutDatabase())
+	if err != nil {
+		return err
+	}
+
+	a, err := newApp(ctx, cfg, false)
+	if err != nil {
+		return err
+	}
+	defer a.close()
+
+	filter, err := entity.NewFilter(cfg.IncludeEntities, cfg.ExcludeEntities)
+	if err != nil {
+		return err
+	}
+
+	for _, pc := range a.clients {
+		a.log.Info("export started", logger.Profile, pc.label, "format", *format)
+		data, err := pc.client.FullSync()
+		if err != nil {
+			return fmt.Errorf("profile %s: %w", pc.label, err)
+		}
+		if err := export.Write(*output, pc.label, *format, &data, filter); err != nil {
+			return fmt.Errorf("profile %s: %w", pc.label, err)
+		}
+	}
+	a.log.Info("export completed", "output", *output)
+	return nil
+}
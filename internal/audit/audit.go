@@ -0,0 +1,127 @@
+// Package audit находит изменения полей транзакций между сохраненными в базе данных и полученными из ZenMoney.
+package audit
+
+import (
+	"github.com/nemirlev/zenapi"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Типы изменений.
+const (
+	// Update поле транзакции изменилось.
+	Update = "update"
+	// Delete транзакция пропала из ZenMoney. Для нее записываются все поля со старыми значениями.
+	Delete = "delete"
+)
+
+// Fields поля транзакции, изменения которых отслеживаются, в порядке вывода.
+var Fields = []string{
+	"date", "income", "income_instrument", "income_account", "outcome", "outcome_instrument",
+	"outcome_account", "tag", "merchant", "payee", "comment", "hold", "deleted",
+}
+
+// Change изменение одного поля транзакции.
+type Change struct {
+	TransactionID string
+	Type          string
+	Field         string
+	// OldValue значение в базе данных.
+	OldValue string
+	// NewValue значение в ZenMoney, nil для удаленных транзакций.
+	NewValue *string
+	// ChangedAt время изменения в ZenMoney (поле changed), для удаленных транзакций - время синхронизации.
+	ChangedAt time.Time
+}
+
+// Values возвращает отслеживаемые поля транзакции в виде строк. Пустые значения записываются пустой строкой,
+// категории - через запятую.
+func Values(t zenapi.Transaction) map[string]string {
+	return map[string]string{
+		"date":               t.Date,
+		"income":             formatFloat(t.Income),
+		"income_instrument":  strconv.Itoa(t.IncomeInstrument),
+		"income_account":     t.IncomeAccount,
+		"outcome":            formatFloat(t.Outcome),
+		"outcome_instrument": strconv.Itoa(t.OutcomeInstrument),
+		"outcome_account":    t.OutcomeAccount,
+		"tag":                strings.Join(t.Tag, ","),
+		"merchant":           formatString(t.Merchant),
+		"payee":              t.Payee,
+		"comment":            t.Comment,
+		"hold":               formatBool(t.Hold),
+		"deleted":            strconv.FormatBool(t.Deleted),
+	}
+}
+
+// Compare сравнивает сохраненные транзакции stored с полученными из ZenMoney incoming. Поля сравниваются только
+// у транзакций с другим значением changed. Транзакции из stored, которых нет в incoming, считаются удаленными
+// моментом observedAt. Изменения отсортированы по идентификатору транзакции и порядку полей Fields.
+func Compare(stored, incoming []zenapi.Transaction, observedAt time.Time) []Change {
+	byID := make(map[string]zenapi.Transaction, len(incoming))
+	for _, t := range incoming {
+		byID[t.ID] = t
+	}
+
+	sorted := append([]zenapi.Transaction{}, stored...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	var changes []Change
+	for _, old := range sorted {
+		oldValues := Values(old)
+
+		current, ok := byID[old.ID]
+		if !ok {
+			for _, field := range Fields {
+				changes = append(changes, Change{
+					TransactionID: old.ID,
+					Type:          Delete,
+					Field:         field,
+					OldValue:      oldValues[field],
+					ChangedAt:     observedAt,
+				})
+			}
+			continue
+		}
+		if current.Changed == old.Changed {
+			continue
+		}
+
+		newValues := Values(current)
+		for _, field := range Fields {
+			if oldValues[field] == newValues[field] {
+				continue
+			}
+			value := newValues[field]
+			changes = append(changes, Change{
+				TransactionID: old.ID,
+				Type:          Update,
+				Field:         field,
+				OldValue:      oldValues[field],
+				NewValue:      &value,
+				ChangedAt:     time.Unix(int64(current.Changed), 0),
+			})
+		}
+	}
+	return changes
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func formatString(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
+
+func formatBool(v *bool) string {
+	if v == nil {
+		return ""
+	}
+	return strconv.FormatBool(*v)
+}
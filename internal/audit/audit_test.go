@@ -0,0 +1,43 @@
+package audit
+
+import (
+	"github.com/nemirlev/zenapi"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// Тестируем изменения полей, пропуск транзакций без изменений и удаления
+func TestCompare(t *testing.T) {
+	observedAt := time.Date(2024, 2, 1, 12, 0, 0, 0, time.UTC)
+	merchant := "m1"
+	stored := []zenapi.Transaction{
+		{ID: "b", Changed: 100, Date: "2024-01-10", Outcome: 150.5, Tag: []string{"t1"}},
+		{ID: "a", Changed: 100, Date: "2024-01-05", Outcome: 10},
+		{ID: "c", Changed: 100, Date: "2024-01-07", Income: 20, Merchant: &merchant},
+	}
+	incoming := []zenapi.Transaction{
+		{ID: "a", Changed: 100, Date: "2024-01-06", Outcome: 10},
+		{ID: "b", Changed: 200, Date: "2024-01-11", Outcome: 150.5, Tag: []string{"t1", "t2"}},
+		{ID: "d", Changed: 300, Date: "2024-01-12"},
+	}
+
+	changes := Compare(stored, incoming, observedAt)
+
+	// У "a" не изменилось changed, поэтому поля не сравниваются
+	changedAt := time.Unix(200, 0)
+	newDate, newTag := "2024-01-11", "t1,t2"
+	assert.Equal(t, Change{TransactionID: "b", Type: Update, Field: "date", OldValue: "2024-01-10", NewValue: &newDate, ChangedAt: changedAt}, changes[0])
+	assert.Equal(t, Change{TransactionID: "b", Type: Update, Field: "tag", OldValue: "t1", NewValue: &newTag, ChangedAt: changedAt}, changes[1])
+
+	deleted := changes[2:]
+	assert.Len(t, deleted, len(Fields))
+	for _, c := range deleted {
+		assert.Equal(t, "c", c.TransactionID)
+		assert.Equal(t, Delete, c.Type)
+		assert.Nil(t, c.NewValue)
+		assert.Equal(t, observedAt, c.ChangedAt)
+	}
+	assert.Equal(t, "20", deleted[1].OldValue)
+	assert.Equal(t, "m1", deleted[8].OldValue)
+}
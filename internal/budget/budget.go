@@ -0,0 +1,136 @@
+// Package budget сравнивает бюджеты ДзенМани с фактическими доходами и расходами.
+// Бюджет задает план доходов и расходов пользователя по категории на месяц. Факт по категории включает операции
+// всех ее дочерних категорий, а транзакция относится к своей основной, первой категории. Переводы, долги
+// и корректировки в факт не входят.
+package budget
+
+import (
+	"github.com/nemirlev/zenapi"
+	"github.com/nemirlev/zenexport/internal/category"
+	"github.com/nemirlev/zenexport/internal/currency"
+	"github.com/nemirlev/zenexport/internal/ledger"
+	"sort"
+	"time"
+)
+
+// Total категория бюджета на месяц в целом, в факт которого входят все операции пользователя.
+const Total = "00000000-0000-0000-0000-000000000000"
+
+// Actual план и факт бюджета пользователя по категории за месяц в основной валюте пользователя.
+// Отклонение - факт минус план. Бюджет без категории сравнивается с операциями без категорий.
+type Actual struct {
+	User            int
+	Tag             *string
+	Month           time.Time
+	PlannedIncome   float64
+	ActualIncome    float64
+	IncomeVariance  float64
+	PlannedOutcome  float64
+	ActualOutcome   float64
+	OutcomeVariance float64
+}
+
+// Compare сравнивает бюджеты с транзакциями. Суммы транзакций переводятся в основную валюту пользователя через
+// converter, транзакции, которые не удалось перевести, пропускаются, а их количество возвращается в unconverted.
+// Результат отсортирован по пользователю, месяцу и категории.
+func Compare(data *zenapi.Response, converter *currency.Converter) (result []Actual, unconverted int) {
+	type key struct {
+		user  int
+		month time.Time
+		tag   string
+	}
+	type turnover struct{ income, outcome float64 }
+
+	debts := ledger.Debts(data.Account)
+	turnovers := map[key]turnover{}
+	for _, t := range data.Transaction {
+		if t.Deleted {
+			continue
+		}
+		kind := ledger.Classify(t, debts)
+		if kind != ledger.KindIncome && kind != ledger.KindExpense {
+			continue
+		}
+		date, err := time.Parse(time.DateOnly, t.Date)
+		if err != nil {
+			continue
+		}
+		amounts, err := converter.Transaction(t)
+		if err != nil {
+			unconverted++
+			continue
+		}
+
+		k := key{user: t.User, month: month(date)}
+		if len(t.Tag) > 0 {
+			k.tag = t.Tag[0]
+		}
+		v := turnovers[k]
+		v.income += amounts.Income
+		v.outcome += amounts.Outcome
+		turnovers[k] = v
+	}
+
+	tree := category.NewTree(data.Tag)
+	for _, b := range data.Budget {
+		date, err := time.Parse(time.DateOnly, b.Date)
+		if err != nil {
+			continue
+		}
+		a := Actual{
+			User:           b.User,
+			Tag:            b.Tag,
+			Month:          month(date),
+			PlannedIncome:  b.Income,
+			PlannedOutcome: b.Outcome,
+		}
+		for k, v := range turnovers {
+			if k.user != a.User || !k.month.Equal(a.Month) {
+				continue
+			}
+			if matches(tree, b.Tag, k.tag) {
+				a.ActualIncome += v.income
+				a.ActualOutcome += v.outcome
+			}
+		}
+		a.IncomeVariance = a.ActualIncome - a.PlannedIncome
+		a.OutcomeVariance = a.ActualOutcome - a.PlannedOutcome
+		result = append(result, a)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.User != b.User {
+			return a.User < b.User
+		}
+		if !a.Month.Equal(b.Month) {
+			return a.Month.Before(b.Month)
+		}
+		return tag(a.Tag) < tag(b.Tag)
+	})
+	return result, unconverted
+}
+
+// matches сообщает, входит ли операция с основной категорией tag в факт бюджета по категории budget.
+// У операции без категорий tag пустой.
+func matches(tree *category.Tree, budget *string, tag string) bool {
+	switch {
+	case budget == nil:
+		return tag == ""
+	case *budget == Total:
+		return true
+	default:
+		return tag != "" && tree.Within(tag, *budget)
+	}
+}
+
+func month(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func tag(t *string) string {
+	if t == nil {
+		return ""
+	}
+	return *t
+}
@@ -0,0 +1,64 @@
+package budget
+
+import (
+	"github.com/nemirlev/zenapi"
+	"github.com/nemirlev/zenexport/internal/currency"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+const (
+	rub = 2
+	usd = 1
+)
+
+func ref(s string) *string {
+	return &s
+}
+
+// Тестируем план и факт с дочерними категориями, валютами и бюджетом без категории
+func TestCompare(t *testing.T) {
+	data := &zenapi.Response{
+		Instrument: []zenapi.Instrument{{ID: rub, Rate: 1}, {ID: usd, Rate: 90}},
+		User:       []zenapi.User{{ID: 1, Currency: rub}},
+		Account:    []zenapi.Account{{ID: "card"}, {ID: "cash"}, {ID: "loan", Type: "debt"}},
+		Tag: []zenapi.Tag{
+			{ID: "food"},
+			{ID: "cafe", Parent: ref("food")},
+			{ID: "salary"},
+		},
+		Budget: []zenapi.Budget{
+			{User: 1, Tag: ref("food"), Date: "2024-01-01", Outcome: 500},
+			{User: 1, Tag: ref("salary"), Date: "2024-01-01", Income: 1000},
+			{User: 1, Date: "2024-01-01", Outcome: 10},
+			{User: 1, Tag: ref(Total), Date: "2024-01-01", Outcome: 1000},
+		},
+		Transaction: []zenapi.Transaction{
+			{User: 1, Date: "2024-01-05", OutcomeAccount: "card", IncomeAccount: "card", OutcomeInstrument: rub, Outcome: 300, Tag: []string{"food"}},
+			// Дочерняя категория и расход в долларах
+			{User: 1, Date: "2024-01-10", OutcomeAccount: "card", IncomeAccount: "card", OutcomeInstrument: usd, Outcome: 5, Tag: []string{"cafe", "salary"}},
+			{User: 1, Date: "2024-01-15", IncomeAccount: "card", OutcomeAccount: "card", IncomeInstrument: rub, Income: 1200, Tag: []string{"salary"}},
+			// Операция без категории
+			{User: 1, Date: "2024-01-20", OutcomeAccount: "cash", IncomeAccount: "cash", OutcomeInstrument: rub, Outcome: 15},
+			// Переводы, долги, удаленные операции и другие месяцы в факт не входят
+			{User: 1, Date: "2024-01-21", OutcomeAccount: "card", IncomeAccount: "cash", OutcomeInstrument: rub, IncomeInstrument: rub, Outcome: 50, Income: 50},
+			{User: 1, Date: "2024-01-22", OutcomeAccount: "card", IncomeAccount: "loan", OutcomeInstrument: rub, IncomeInstrument: rub, Outcome: 70, Income: 70, Tag: []string{"food"}},
+			{User: 1, Date: "2024-01-23", OutcomeAccount: "card", IncomeAccount: "card", OutcomeInstrument: rub, Outcome: 80, Tag: []string{"food"}, Deleted: true},
+			{User: 1, Date: "2024-02-01", OutcomeAccount: "card", IncomeAccount: "card", OutcomeInstrument: rub, Outcome: 90, Tag: []string{"food"}},
+			// Валюта без курса
+			{User: 1, Date: "2024-01-24", OutcomeAccount: "card", IncomeAccount: "card", OutcomeInstrument: 7, Outcome: 1, Tag: []string{"food"}},
+		},
+	}
+
+	result, unconverted := Compare(data, currency.New(data))
+
+	january := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, 1, unconverted)
+	assert.Equal(t, []Actual{
+		{User: 1, Month: january, PlannedOutcome: 10, ActualOutcome: 15, OutcomeVariance: 5},
+		{User: 1, Tag: ref(Total), Month: january, ActualIncome: 1200, IncomeVariance: 1200, PlannedOutcome: 1000, ActualOutcome: 765, OutcomeVariance: -235},
+		{User: 1, Tag: ref("food"), Month: january, PlannedOutcome: 500, ActualOutcome: 750, OutcomeVariance: 250},
+		{User: 1, Tag: ref("salary"), Month: january, PlannedIncome: 1000, ActualIncome: 1200, IncomeVariance: 200},
+	}, result)
+}
@@ -0,0 +1,144 @@
+// Package category разворачивает иерархию категорий ДзенМани. Категория ссылается на родителя через tag.parent,
+// а транзакция хранит список категорий, первая из которых основная.
+package category
+
+import (
+	"github.com/nemirlev/zenapi"
+	"sort"
+	"strings"
+)
+
+// Separator разделитель названий категорий в полном пути.
+const Separator = " / "
+
+// Path положение категории в иерархии.
+type Path struct {
+	Tag   string
+	User  int
+	Title string
+	// Root корневая категория, для корня - сама категория.
+	Root      string
+	RootTitle string
+	// Subcategory категория второго уровня на пути к категории, у корня ее нет.
+	Subcategory      *string
+	SubcategoryTitle *string
+	// Path названия категорий от корня, разделенные Separator.
+	Path  string
+	Depth int
+}
+
+// TransactionTag связь транзакции с категорией. Основная категория транзакции - первая в списке.
+type TransactionTag struct {
+	Transaction string
+	Tag         string
+	Position    int
+	Primary     bool
+}
+
+// Tree иерархия категорий.
+type Tree struct {
+	tags map[string]zenapi.Tag
+}
+
+// NewTree строит иерархию категорий. Категория, родителя которой нет среди tags, считается корневой.
+func NewTree(tags []zenapi.Tag) *Tree {
+	t := &Tree{tags: make(map[string]zenapi.Tag, len(tags))}
+	for _, tag := range tags {
+		t.tags[tag.ID] = tag
+	}
+	return t
+}
+
+// Ancestors возвращает категории от корня до id включительно. Для неизвестной категории возвращается nil.
+// Цикл в ссылках на родителей обрывается на первой повторившейся категории.
+func (t *Tree) Ancestors(id string) []zenapi.Tag {
+	var chain []zenapi.Tag
+	seen := map[string]bool{}
+	for {
+		tag, ok := t.tags[id]
+		if !ok || seen[id] {
+			break
+		}
+		seen[id] = true
+		chain = append(chain, tag)
+		if tag.Parent == nil {
+			break
+		}
+		id = *tag.Parent
+	}
+
+	for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+		chain[i], chain[j] = chain[j], chain[i]
+	}
+	return chain
+}
+
+// Within сообщает, является ли категория id категорией ancestor или ее потомком.
+func (t *Tree) Within(id, ancestor string) bool {
+	for _, tag := range t.Ancestors(id) {
+		if tag.ID == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+// Paths возвращает положение в иерархии каждой категории, отсортированное по полному пути.
+func (t *Tree) Paths() []Path {
+	paths := make([]Path, 0, len(t.tags))
+	for id, tag := range t.tags {
+		chain := t.Ancestors(id)
+		titles := make([]string, len(chain))
+		for i, c := range chain {
+			titles[i] = c.Title
+		}
+
+		p := Path{
+			Tag:       tag.ID,
+			User:      tag.User,
+			Title:     tag.Title,
+			Root:      chain[0].ID,
+			RootTitle: chain[0].Title,
+			Path:      strings.Join(titles, Separator),
+			Depth:     len(chain) - 1,
+		}
+		if len(chain) > 1 {
+			p.Subcategory = &chain[1].ID
+			p.SubcategoryTitle = &chain[1].Title
+		}
+		paths = append(paths, p)
+	}
+
+	sort.Slice(paths, func(i, j int) bool {
+		if paths[i].Path != paths[j].Path {
+			return paths[i].Path < paths[j].Path
+		}
+		return paths[i].Tag < paths[j].Tag
+	})
+	return paths
+}
+
+// TransactionTags возвращает связи транзакций с категориями. Удаленные транзакции пропускаются, повторы категории
+// в транзакции учитываются один раз.
+func TransactionTags(transactions []zenapi.Transaction) []TransactionTag {
+	var result []TransactionTag
+	for _, t := range transactions {
+		if t.Deleted {
+			continue
+		}
+		seen := map[string]bool{}
+		for _, tag := range t.Tag {
+			if seen[tag] {
+				continue
+			}
+			seen[tag] = true
+			result = append(result, TransactionTag{
+				Transaction: t.ID,
+				Tag:         tag,
+				Position:    len(seen) - 1,
+				Primary:     len(seen) == 1,
+			})
+		}
+	}
+	return result
+}
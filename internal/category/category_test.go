@@ -0,0 +1,73 @@
+package category
+
+import (
+	"github.com/nemirlev/zenapi"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func ref(s string) *string {
+	return &s
+}
+
+var tags = []zenapi.Tag{
+	{ID: "food", User: 1, Title: "Еда"},
+	{ID: "cafe", User: 1, Title: "Кафе", Parent: ref("food")},
+	{ID: "coffee", User: 1, Title: "Кофе", Parent: ref("cafe")},
+	// Родителя нет среди категорий
+	{ID: "orphan", User: 1, Title: "Сирота", Parent: ref("gone")},
+	// Цикл в ссылках на родителей
+	{ID: "a", Title: "A", Parent: ref("b")},
+	{ID: "b", Title: "B", Parent: ref("a")},
+}
+
+// Тестируем пути категорий
+func TestPaths(t *testing.T) {
+	paths := NewTree(tags).Paths()
+
+	byTag := map[string]Path{}
+	for _, p := range paths {
+		byTag[p.Tag] = p
+	}
+
+	assert.Len(t, paths, len(tags))
+	assert.Equal(t, Path{Tag: "food", User: 1, Title: "Еда", Root: "food", RootTitle: "Еда", Path: "Еда"}, byTag["food"])
+	assert.Equal(t, Path{
+		Tag:              "coffee",
+		User:             1,
+		Title:            "Кофе",
+		Root:             "food",
+		RootTitle:        "Еда",
+		Subcategory:      ref("cafe"),
+		SubcategoryTitle: ref("Кафе"),
+		Path:             "Еда / Кафе / Кофе",
+		Depth:            2,
+	}, byTag["coffee"])
+	assert.Equal(t, "orphan", byTag["orphan"].Root)
+	assert.Equal(t, "A / B", byTag["b"].Path)
+	assert.Equal(t, "Еда", paths[2].Path)
+}
+
+// Тестируем принадлежность категории ветке иерархии
+func TestWithin(t *testing.T) {
+	tree := NewTree(tags)
+
+	assert.True(t, tree.Within("coffee", "food"))
+	assert.True(t, tree.Within("cafe", "cafe"))
+	assert.False(t, tree.Within("food", "cafe"))
+	assert.False(t, tree.Within("gone", "gone"))
+}
+
+// Тестируем связи транзакций с категориями
+func TestTransactionTags(t *testing.T) {
+	links := TransactionTags([]zenapi.Transaction{
+		{ID: "t1", Tag: []string{"cafe", "food", "cafe"}},
+		{ID: "t2"},
+		{ID: "t3", Tag: []string{"food"}, Deleted: true},
+	})
+
+	assert.Equal(t, []TransactionTag{
+		{Transaction: "t1", Tag: "cafe", Position: 0, Primary: true},
+		{Transaction: "t1", Tag: "food", Position: 1},
+	}, links)
+}
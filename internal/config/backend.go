@@ -0,0 +1,80 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Backend описывает настройки конкретной базы данных. Бэкенды регистрируются фабрикой хранилищ,
+// поэтому проверяются только настройки выбранной в DATABASE_TYPE базы.
+type Backend struct {
+	// Required пути обязательных настроек в файле конфигурации, например database.clickhouse.server.
+	Required []string
+	// Validate дополнительная проверка настроек бэкенда. Может быть nil.
+	Validate func(c Config) []Problem
+}
+
+var (
+	backendsMu sync.RWMutex
+	backends   = map[string]Backend{}
+)
+
+// RegisterBackend регистрирует схему настроек базы данных с типом name.
+func RegisterBackend(name string, b Backend) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+	backends[name] = b
+}
+
+// lookupBackend возвращает зарегистрированный бэкенд и список всех известных типов баз данных.
+func lookupBackend(name string) (Backend, []string, bool) {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+
+	names := make([]string, 0, len(backends))
+	for n := range backends {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	b, ok := backends[name]
+	return b, names, ok
+}
+
+// validateBackend проверяет настройки выбранной базы данных.
+func (c Config) validateBackend() []Problem {
+	b, names, ok := lookupBackend(c.DatabaseType)
+	if !ok {
+		return []Problem{{
+			Field:   "database.type",
+			Message: fmt.Sprintf("unsupported database type %q, supported: %s", c.DatabaseType, strings.Join(names, ", ")),
+		}}
+	}
+
+	var problems []Problem
+	for _, field := range b.Required {
+		if c.isEmpty(field) {
+			problems = append(problems, Problem{Field: field, Message: "is required"})
+		}
+	}
+	if b.Validate != nil {
+		problems = append(problems, b.Validate(c)...)
+	}
+	return problems
+}
+
+// isEmpty проверяет, что настройка с путем field не задана. Поле структуры находится по тегу mapstructure,
+// совпадающему с именем переменной окружения этой настройки.
+func (c Config) isEmpty(field string) bool {
+	env := fieldEnv(field)
+	value := reflect.ValueOf(c)
+	for i := 0; i < value.NumField(); i++ {
+		if value.Type().Field(i).Tag.Get("mapstructure") == env {
+			return value.Field(i).IsZero()
+		}
+	}
+	return true
+}
@@ -9,18 +9,42 @@ import (
 )
 
 type Config struct {
-	ZenMoneyToken      string `mapstructure:"ZENMONEY_TOKEN"`
-	IsDaemon           bool   `mapstructure:"IS_DAEMON"`
-	DatabaseType       string `mapstructure:"DATABASE_TYPE"`
-	DatabaseServer     string `mapstructure:"DATABASE_SERVER"`
-	DatabaseUser       string `mapstructure:"DATABASE_USER"`
-	DatabasePassword   string `mapstructure:"DATABASE_PASSWORD"`
-	DatabaseName       string `mapstructure:"DATABASE_NAME"`
-	ClickhouseServer   string `mapstructure:"CLICKHOUSE_SERVER"`
-	ClickhouseUser     string `mapstructure:"CLICKHOUSE_USER"`
-	ClickhouseDB       string `mapstructure:"CLICKHOUSE_DB"`
-	ClickhousePassword string `mapstructure:"CLICKHOUSE_PASSWORD"`
-	Interval           int    `mapstructure:"INTERVAL"`
+	ZenMoneyToken      string    `mapstructure:"ZENMONEY_TOKEN"`
+	ZenMoneyProfiles   []Profile `mapstructure:"ZENMONEY_PROFILES"`
+	IsDaemon           bool      `mapstructure:"IS_DAEMON"`
+	DatabaseType       string    `mapstructure:"DATABASE_TYPE"`
+	DatabaseServer     string    `mapstructure:"DATABASE_SERVER"`
+	DatabaseUser       string    `mapstructure:"DATABASE_USER"`
+	DatabasePassword   string    `mapstructure:"DATABASE_PASSWORD"`
+	DatabaseName       string    `mapstructure:"DATABASE_NAME"`
+	ClickhouseServer   string    `mapstructure:"CLICKHOUSE_SERVER"`
+	ClickhouseUser     string    `mapstructure:"CLICKHOUSE_USER"`
+	ClickhouseDB       string    `mapstructure:"CLICKHOUSE_DB"`
+	ClickhousePassword string    `mapstructure:"CLICKHOUSE_PASSWORD"`
+	ClickhouseCluster  string    `mapstructure:"CLICKHOUSE_CLUSTER"`
+	Interval           int       `mapstructure:"INTERVAL"`
+	BatchSize          int       `mapstructure:"BATCH_SIZE"`
+	AsyncInsert        bool      `mapstructure:"ASYNC_INSERT"`
+	Workers            int       `mapstructure:"WORKERS"`
+	IncludeEntities    []string  `mapstructure:"INCLUDE_ENTITIES"`
+	ExcludeEntities    []string  `mapstructure:"EXCLUDE_ENTITIES"`
+	Migrate            bool      `mapstructure:"MIGRATE"`
+	DryRun             bool      `mapstructure:"DRY_RUN"`
+	DryRunReport       string    `mapstructure:"DRY_RUN_REPORT"`
+	Since              string    `mapstructure:"SINCE"`
+	Until              string    `mapstructure:"UNTIL"`
+	History            bool      `mapstructure:"HISTORY"`
+	ForecastDays       int       `mapstructure:"FORECAST_DAYS"`
+	LogLevel           string    `mapstructure:"LOG_LEVEL"`
+	LogFormat          string    `mapstructure:"LOG_FORMAT"`
+	LogFile            string    `mapstructure:"LOG_FILE"`
+	TracingExporter    string    `mapstructure:"TRACING_EXPORTER"`
+	TracingEndpoint    string    `mapstructure:"TRACING_ENDPOINT"`
+	NotifyWebhookURL   string    `mapstructure:"NOTIFY_WEBHOOK_URL"`
+	NotifyCommand      string    `mapstructure:"NOTIFY_COMMAND"`
+	NotifyOn           string    `mapstructure:"NOTIFY_ON"`
+	NotifyThreshold    int       `mapstructure:"NOTIFY_FAILURE_THRESHOLD"`
+	ConfigFile         string    `mapstructure:"CONFIG_FILE"`
 }
 
 // DatabaseURL возращает строку подключения к базе данных
@@ -35,6 +59,7 @@ func initViper() *viper.Viper {
 	v.AutomaticEnv()
 
 	v.SetDefault("ZENMONEY_TOKEN", "")
+	v.SetDefault("ZENMONEY_PROFILES", []Profile{})
 	v.SetDefault("IS_DAEMON", false)
 	v.SetDefault("DATABASE_TYPE", "clickhouse")
 	v.SetDefault("DATABASE_SERVER", "127.0.0.1")
@@ -45,122 +70,253 @@ func initViper() *viper.Viper {
 	v.SetDefault("CLICKHOUSE_USER", "")
 	v.SetDefault("CLICKHOUSE_DB", "")
 	v.SetDefault("CLICKHOUSE_PASSWORD", "")
+	v.SetDefault("CLICKHOUSE_CLUSTER", "")
 	v.SetDefault("INTERVAL", 1)
+	v.SetDefault("BATCH_SIZE", 10000)
+	v.SetDefault("ASYNC_INSERT", false)
+	v.SetDefault("WORKERS", 4)
+	v.SetDefault("INCLUDE_ENTITIES", []string{})
+	v.SetDefault("EXCLUDE_ENTITIES", []string{})
+	v.SetDefault("MIGRATE", false)
+	v.SetDefault("DRY_RUN", false)
+	v.SetDefault("DRY_RUN_REPORT", "")
+	v.SetDefault("SINCE", "")
+	v.SetDefault("UNTIL", "")
+	v.SetDefault("HISTORY", false)
+	v.SetDefault("FORECAST_DAYS", 90)
+	v.SetDefault("LOG_LEVEL", "info")
+	v.SetDefault("LOG_FORMAT", "json")
+	v.SetDefault("LOG_FILE", "")
+	v.SetDefault("TRACING_EXPORTER", "none")
+	v.SetDefault("TRACING_ENDPOINT", "")
+	v.SetDefault("NOTIFY_WEBHOOK_URL", "")
+	v.SetDefault("NOTIFY_COMMAND", "")
+	v.SetDefault("NOTIFY_ON", "all")
+	v.SetDefault("NOTIFY_FAILURE_THRESHOLD", 3)
+	v.SetDefault("CONFIG_FILE", "")
 
 	return v
 }
 
-// FromEnv загружает конфигурацию из переменных окружения и флагов
+// FromEnv загружает конфигурацию из файла, переменных окружения и флагов командной строки.
+// Приоритет источников: файл конфигурации < переменные окружения < флаги.
 func FromEnv() (*Config, error) {
+	// Проверка, не тест ли это
+	if isTestEnvironment() {
+		return Load(nil)
+	}
+
+	if !flag.Parsed() {
+		defineFlags()
+		flag.Parse()
+	}
+	return Load(flag.CommandLine)
+}
+
+// Option меняет проверку конфигурации для команд, которым нужны не все настройки.
+type Option func(o *options)
+
+type options struct {
+	skipToken    bool
+	skipDatabase bool
+	daemon       bool
+}
+
+// WithoutToken отключает проверку токенов ZenMoney, например для команд, работающих только с базой данных.
+func WithoutToken() Option {
+	return func(o *options) { o.skipToken = true }
+}
+
+// WithoutDatabase отключает проверку настроек базы данных, например для выгрузки в файлы.
+func WithoutDatabase() Option {
+	return func(o *options) { o.skipDatabase = true }
+}
+
+// AsDaemon включает режим демона до проверки конфигурации, чтобы проверялись и настройки расписания.
+func AsDaemon() Option {
+	return func(o *options) { o.daemon = true }
+}
+
+// Load загружает конфигурацию, используя уже разобранный набор флагов fs. Флаги, которые не определены в fs,
+// не учитываются, поэтому каждая команда может определить только нужные ей флаги. fs может быть nil.
+func Load(fs *flag.FlagSet, opts ...Option) (*Config, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	v := initViper()
 
-	// Проверка, не тест ли это
-	if !isTestEnvironment() {
-		if !flag.Parsed() {
-			defineFlags()
-			flag.Parse()
+	// Переопределение переменных окружения значениями флагов при их наличии
+	applyFlagOverrides(v, fs)
+
+	// Значения из файла конфигурации заменяют значения по умолчанию, но не переменные окружения и флаги
+	var problems []Problem
+	if path := v.GetString("CONFIG_FILE"); path != "" {
+		fileProblems, err := applyConfigFile(v, path)
+		if err != nil {
+			return nil, err
 		}
+		problems = append(problems, fileProblems...)
 	}
 
-	// Переопределение переменных окружения значениями флагов при их наличии
-	applyFlagOverrides(v)
+	// Секреты из файлов <KEY>_FILE
+	secretProblems, err := applySecretFiles(v)
+	if err != nil {
+		return nil, err
+	}
+	problems = append(problems, secretProblems...)
 
 	cfg := &Config{}
-	if err := v.Unmarshal(cfg); err != nil {
+	if err := v.Unmarshal(cfg, viper.DecodeHook(decodeHook())); err != nil {
 		return nil, fmt.Errorf("error unmarshaling config: %w", err)
 	}
-
-	if cfg.ClickhouseUser == "" {
-		return nil, fmt.Errorf("CLICKHOUSE_USER is required")
+	if err := cfg.resolveProfiles(); err != nil {
+		return nil, err
 	}
-
-	if cfg.ClickhouseDB == "" {
-		return nil, fmt.Errorf("CLICKHOUSE_DB is required")
+	if o.daemon {
+		cfg.IsDaemon = true
 	}
+	cfg.IncludeEntities = trimList(cfg.IncludeEntities)
+	cfg.ExcludeEntities = trimList(cfg.ExcludeEntities)
 
-	if cfg.ClickhousePassword == "" {
-		return nil, fmt.Errorf("CLICKHOUSE_PASSWORD is required")
+	problems = append(problems, cfg.validate(o)...)
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
 	}
 
 	return cfg, nil
 }
 
-// defineFlags определяет флаги
+// defineFlags определяет все флаги в глобальном наборе для запуска без команды
 func defineFlags() {
-	flag.Int("interval", 0, "The interval in minutes to wait between syncs")
-	flag.String("token", "", "The ZenMoney token. Get it from https://zerro.app/token")
-	flag.String("dbtype", "", "The type of the database")
+	DefineFlags(flag.CommandLine)
+	DefineSyncFlags(flag.CommandLine)
+	DefineScheduleFlags(flag.CommandLine)
 	flag.Bool("d", false, "Run as a daemon")
-	flag.String("server", "", "The ClickHouse server")
-	flag.String("user", "", "The ClickHouse user")
-	flag.String("db", "", "The ClickHouse database")
-	flag.String("password", "", "The ClickHouse password")
-}
-
-// applyFlagOverrides переопределяет переменные окружения значениями флагов при их наличии
-func applyFlagOverrides(v *viper.Viper) {
-	intervalFlag := flag.Lookup("interval")
-	if intervalFlag != nil {
-		intervalVal, ok := intervalFlag.Value.(flag.Getter)
-		if ok && intervalVal.Get().(int) != 0 {
-			v.Set("INTERVAL", intervalVal.Get().(int))
-		}
-	}
+}
 
-	tokenFlag := flag.Lookup("token")
-	if tokenFlag != nil {
-		tokenVal, ok := tokenFlag.Value.(flag.Getter)
-		if ok && tokenVal.Get().(string) != "" {
-			v.Set("ZENMONEY_TOKEN", tokenVal.Get().(string))
-		}
-	}
+// DefineFlags определяет общие флаги: файл конфигурации, профили ZenMoney, подключение к базе данных и фильтры сущностей
+func DefineFlags(fs *flag.FlagSet) {
+	fs.String("config", "", "Path to a YAML or TOML config file")
+	fs.String("token", "", "The ZenMoney token. Get it from https://zerro.app/token")
+	fs.String("profiles", "", "Comma-separated ZenMoney profiles in label=token format")
+	fs.String("dbtype", "", "The type of the database")
+	fs.String("server", "", "The ClickHouse server")
+	fs.String("user", "", "The ClickHouse user")
+	fs.String("db", "", "The ClickHouse database")
+	fs.String("password", "", "The ClickHouse password")
+	fs.String("cluster", "", "The ClickHouse cluster name, enables ON CLUSTER DDL and Distributed tables")
+	fs.String("include", "", "Comma-separated list of entities to export, all by default")
+	fs.String("exclude", "", "Comma-separated list of entities to skip")
+	fs.String("log-level", "", "Log level: debug, info, warn, error")
+	fs.String("log-format", "", "Log format: json, text, pretty")
+	fs.String("log-file", "", "Write logs to this file instead of stdout")
+	fs.String("tracing", "", "OpenTelemetry span exporter: none, otlp, stdout")
+	fs.String("tracing-endpoint", "", "OTLP/HTTP collector endpoint, e.g. http://localhost:4318")
+}
 
-	dbTypeFlag := flag.Lookup("dbtype")
-	if dbTypeFlag != nil {
-		dbTypeVal, ok := dbTypeFlag.Value.(flag.Getter)
-		if ok && dbTypeVal.Get().(string) != "" {
-			v.Set("DATABASE_TYPE", dbTypeVal.Get().(string))
-		}
-	}
+// DefineSyncFlags определяет флаги записи в базу данных
+func DefineSyncFlags(fs *flag.FlagSet) {
+	fs.Int("batch-size", 0, "The number of rows sent to the database in one chunk")
+	fs.Bool("async-insert", false, "Use ClickHouse async inserts")
+	fs.Int("workers", 0, "The number of tables saved in parallel")
+	fs.Bool("migrate", false, "Apply database migrations before export")
+	fs.Bool("dry-run", false, "Show what would change in the database without writing anything")
+	fs.String("dry-run-report", "", "Path to a JSON report of the dry run")
+	fs.Bool("history", false, "Keep valid_from/valid_to history of accounts, tags and merchants")
+	fs.Int("forecast-days", 0, "The number of days ahead reminders are expanded into forecast transactions")
+	DefinePeriodFlags(fs)
+	DefineNotifyFlags(fs)
+}
 
-	// Server
-	serverFlag := flag.Lookup("server")
-	if serverFlag != nil {
-		serverVal, ok := serverFlag.Value.(flag.Getter)
-		if ok && serverVal.Get().(string) != "" {
-			v.Set("CLICKHOUSE_SERVER", serverVal.Get().(string))
-		}
-	}
+// DefineNotifyFlags определяет флаги уведомлений о завершении синхронизации
+func DefineNotifyFlags(fs *flag.FlagSet) {
+	fs.String("notify-webhook", "", "URL to POST a JSON summary of every sync run to")
+	fs.String("notify-command", "", "Shell command run after every sync run with a JSON summary on stdin")
+	fs.String("notify-on", "", "When to notify: all, failure, alert")
+	fs.Int("notify-failure-threshold", 0, "The number of consecutive failed runs that raises an alert")
+}
 
-	userFlag := flag.Lookup("user")
-	if userFlag != nil {
-		userVal, ok := userFlag.Value.(flag.Getter)
-		if ok && userVal.Get().(string) != "" {
-			v.Set("CLICKHOUSE_USER", userVal.Get().(string))
-		}
-	}
+// DefinePeriodFlags определяет флаги периода, за который выгружаются бюджеты, отметки напоминаний и транзакции
+func DefinePeriodFlags(fs *flag.FlagSet) {
+	fs.String("since", "", "Export budgets, reminder markers and transactions dated on or after this day (YYYY-MM-DD)")
+	fs.String("until", "", "Export budgets, reminder markers and transactions dated on or before this day (YYYY-MM-DD)")
+}
 
-	dbFlag := flag.Lookup("db")
-	if dbFlag != nil {
-		dbVal, ok := dbFlag.Value.(flag.Getter)
-		if ok && dbVal.Get().(string) != "" {
-			v.Set("CLICKHOUSE_DB", dbVal.Get().(string))
-		}
-	}
+// DefineScheduleFlags определяет флаги режима демона
+func DefineScheduleFlags(fs *flag.FlagSet) {
+	fs.Int("interval", 0, "The interval in minutes to wait between syncs")
+}
 
-	passwordFlag := flag.Lookup("password")
-	if passwordFlag != nil {
-		passwordVal, ok := passwordFlag.Value.(flag.Getter)
-		if ok && passwordVal.Get().(string) != "" {
-			v.Set("CLICKHOUSE_PASSWORD", passwordVal.Get().(string))
-		}
+// flagKeys сопоставляет флаги командной строки ключам конфигурации, которые они переопределяют.
+var flagKeys = []struct {
+	flag string
+	key  string
+}{
+	{"interval", "INTERVAL"},
+	{"token", "ZENMONEY_TOKEN"},
+	{"profiles", "ZENMONEY_PROFILES"},
+	{"dbtype", "DATABASE_TYPE"},
+	{"server", "CLICKHOUSE_SERVER"},
+	{"user", "CLICKHOUSE_USER"},
+	{"db", "CLICKHOUSE_DB"},
+	{"password", "CLICKHOUSE_PASSWORD"},
+	{"cluster", "CLICKHOUSE_CLUSTER"},
+	{"batch-size", "BATCH_SIZE"},
+	{"async-insert", "ASYNC_INSERT"},
+	{"workers", "WORKERS"},
+	{"include", "INCLUDE_ENTITIES"},
+	{"exclude", "EXCLUDE_ENTITIES"},
+	{"migrate", "MIGRATE"},
+	{"dry-run", "DRY_RUN"},
+	{"dry-run-report", "DRY_RUN_REPORT"},
+	{"history", "HISTORY"},
+	{"forecast-days", "FORECAST_DAYS"},
+	{"since", "SINCE"},
+	{"until", "UNTIL"},
+	{"log-level", "LOG_LEVEL"},
+	{"log-format", "LOG_FORMAT"},
+	{"log-file", "LOG_FILE"},
+	{"tracing", "TRACING_EXPORTER"},
+	{"tracing-endpoint", "TRACING_ENDPOINT"},
+	{"notify-webhook", "NOTIFY_WEBHOOK_URL"},
+	{"notify-command", "NOTIFY_COMMAND"},
+	{"notify-on", "NOTIFY_ON"},
+	{"notify-failure-threshold", "NOTIFY_FAILURE_THRESHOLD"},
+	{"config", "CONFIG_FILE"},
+	{"d", "IS_DAEMON"},
+}
+
+// applyFlagOverrides переопределяет переменные окружения значениями флагов при их наличии.
+// Флаг с нулевым значением (пустая строка, 0 или false) считается не заданным.
+func applyFlagOverrides(v *viper.Viper, fs *flag.FlagSet) {
+	if fs == nil {
+		return
 	}
 
-	daemonFlag := flag.Lookup("d")
-	if daemonFlag != nil {
-		daemonVal, ok := daemonFlag.Value.(flag.Getter)
-		if ok && daemonVal.Get().(bool) {
-			v.Set("IS_DAEMON", daemonVal.Get().(bool))
+	for _, fk := range flagKeys {
+		f := fs.Lookup(fk.flag)
+		if f == nil {
+			continue
+		}
+		getter, ok := f.Value.(flag.Getter)
+		if !ok {
+			continue
+		}
+		switch value := getter.Get().(type) {
+		case string:
+			if value != "" {
+				v.Set(fk.key, value)
+			}
+		case int:
+			if value != 0 {
+				v.Set(fk.key, value)
+			}
+		case bool:
+			if value {
+				v.Set(fk.key, value)
+			}
 		}
 	}
 }
@@ -173,3 +329,15 @@ func isTestEnvironment() bool {
 	}
 	return false
 }
+
+// trimList убирает пробелы вокруг элементов списка и пустые элементы, которые остаются после разбора
+// строки вида "transaction, account,".
+func trimList(list []string) []string {
+	var trimmed []string
+	for _, item := range list {
+		if item = strings.TrimSpace(item); item != "" {
+			trimmed = append(trimmed, item)
+		}
+	}
+	return trimmed
+}
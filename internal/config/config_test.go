@@ -4,9 +4,21 @@ import (
 	"flag"
 	"github.com/stretchr/testify/assert"
 	"os"
+	"path/filepath"
 	"testing"
 )
 
+// Регистрируем тестовые схемы баз данных. Настоящие схемы регистрирует фабрика хранилищ в пакете db.
+func TestMain(m *testing.M) {
+	RegisterBackend("clickhouse", Backend{
+		Required: []string{"database.clickhouse.server", "database.clickhouse.user", "database.clickhouse.db"},
+	})
+	RegisterBackend("postgresql", Backend{
+		Required: []string{"database.server", "database.user", "database.name"},
+	})
+	os.Exit(m.Run())
+}
+
 // Тестируем при назначении всех переменных
 func TestFromEnvAllEnv(t *testing.T) {
 	// Установка переменных окружения
@@ -53,7 +65,7 @@ func TestFromEnvMissingClickhouseUser(t *testing.T) {
 	// Установка переменных окружения
 	os.Setenv("ZENMONEY_TOKEN", "test_token")
 	os.Setenv("IS_DAEMON", "true")
-	os.Setenv("DATABASE_TYPE", "postgresql")
+	os.Setenv("DATABASE_TYPE", "clickhouse")
 	os.Setenv("DATABASE_SERVER", "localhost")
 	os.Setenv("DATABASE_USER", "test_user")
 	os.Setenv("DATABASE_PASSWORD", "test_password")
@@ -72,7 +84,7 @@ func TestFromEnvMissingClickhouseUser(t *testing.T) {
 	// Проверка, что возвращаемое значение nil
 	assert.Nil(t, cfg)
 	// Проверка, что ошибка - это ожидаемая ошибка
-	assert.Equal(t, "CLICKHOUSE_USER is required", err.Error())
+	assert.Equal(t, "invalid config: database.clickhouse.user (CLICKHOUSE_USER) is required", err.Error())
 
 	// Очистка переменных окружения
 	os.Clearenv()
@@ -84,7 +96,7 @@ func TestFromEnvMissingClickhouseDB(t *testing.T) {
 	// Установка переменных окружения
 	os.Setenv("ZENMONEY_TOKEN", "test_token")
 	os.Setenv("IS_DAEMON", "true")
-	os.Setenv("DATABASE_TYPE", "postgresql")
+	os.Setenv("DATABASE_TYPE", "clickhouse")
 	os.Setenv("DATABASE_SERVER", "localhost")
 	os.Setenv("DATABASE_USER", "test_user")
 	os.Setenv("DATABASE_PASSWORD", "test_password")
@@ -103,7 +115,7 @@ func TestFromEnvMissingClickhouseDB(t *testing.T) {
 	// Проверка, что возвращаемое значение nil
 	assert.Nil(t, cfg)
 	// Проверка, что ошибка - это ожидаемая ошибка
-	assert.Equal(t, "CLICKHOUSE_DB is required", err.Error())
+	assert.Equal(t, "invalid config: database.clickhouse.db (CLICKHOUSE_DB) is required", err.Error())
 
 	// Очистка переменных окружения
 	os.Clearenv()
@@ -114,30 +126,468 @@ func TestFromEnvMissingClickhouseDB(t *testing.T) {
 func TestFromEnvMissingClickhousePassword(t *testing.T) {
 	// Установка переменных окружения
 	os.Setenv("ZENMONEY_TOKEN", "test_token")
-	os.Setenv("IS_DAEMON", "true")
+	os.Setenv("DATABASE_TYPE", "clickhouse")
+	os.Setenv("CLICKHOUSE_SERVER", "localhost")
+	os.Setenv("CLICKHOUSE_USER", "test_user")
+	os.Setenv("CLICKHOUSE_DB", "test_db")
+	// Пропускаем CLICKHOUSE_PASSWORD
+
+	// Вызов функции FromEnv
+	cfg, err := FromEnv()
+
+	// Проверка, что локальная установка без пароля допустима
+	assert.NoError(t, err)
+	assert.Equal(t, "", cfg.ClickhousePassword)
+
+	// Очистка переменных окружения
+	os.Clearenv()
+	// Сброс флагов
+	flag.CommandLine = flag.NewFlagSet("", flag.ExitOnError)
+}
+
+func TestFromEnvOtherBackendIgnoresClickhouse(t *testing.T) {
+	// Установка переменных окружения только для PostgreSQL
+	os.Setenv("ZENMONEY_TOKEN", "test_token")
 	os.Setenv("DATABASE_TYPE", "postgresql")
 	os.Setenv("DATABASE_SERVER", "localhost")
 	os.Setenv("DATABASE_USER", "test_user")
-	os.Setenv("DATABASE_PASSWORD", "test_password")
-	os.Setenv("DATABASE_NAME", "test_db")
-	os.Setenv("CLICKHOUSE_SERVER", "localhost")
+	// Пропускаем DATABASE_NAME
+
+	// Вызов функции FromEnv
+	cfg, err := FromEnv()
+
+	// Проверка, что требуются только настройки выбранной базы данных
+	assert.Nil(t, cfg)
+	assert.Equal(t, "invalid config: database.name (DATABASE_NAME) is required", err.Error())
+
+	// Очистка переменных окружения
+	os.Clearenv()
+	// Сброс флагов
+	flag.CommandLine = flag.NewFlagSet("", flag.ExitOnError)
+}
+
+func TestFromEnvMissingToken(t *testing.T) {
+	// Установка переменных окружения
+	os.Setenv("DATABASE_TYPE", "clickhouse")
 	os.Setenv("CLICKHOUSE_USER", "test_user")
 	os.Setenv("CLICKHOUSE_DB", "test_db")
-	// Пропускаем CLICKHOUSE_PASSWORD
-	os.Setenv("INTERVAL", "1")
+	// Пропускаем ZENMONEY_TOKEN
 
 	// Вызов функции FromEnv
 	cfg, err := FromEnv()
 
-	// Проверка, что функция возвращает ошибку
+	// Проверка, что токен обязателен
+	assert.Nil(t, cfg)
+	assert.Equal(t, "invalid config: zenmoney.token (ZENMONEY_TOKEN) is required", err.Error())
+
+	// Очистка переменных окружения
+	os.Clearenv()
+	// Сброс флагов
+	flag.CommandLine = flag.NewFlagSet("", flag.ExitOnError)
+}
+
+func TestFromEnvUnknownEntity(t *testing.T) {
+	// Установка переменных окружения
+	os.Setenv("ZENMONEY_TOKEN", "test_token")
+	os.Setenv("CLICKHOUSE_USER", "test_user")
+	os.Setenv("CLICKHOUSE_DB", "test_db")
+	os.Setenv("CLICKHOUSE_PASSWORD", "test_password")
+	os.Setenv("INCLUDE_ENTITIES", "transaction,accounts")
+
+	// Вызов функции FromEnv
+	cfg, err := FromEnv()
+
+	// Проверка, что неизвестная сущность приводит к ошибке
 	assert.Error(t, err)
-	// Проверка, что возвращаемое значение nil
 	assert.Nil(t, cfg)
-	// Проверка, что ошибка - это ожидаемая ошибка
-	assert.Equal(t, "CLICKHOUSE_PASSWORD is required", err.Error())
+	assert.Equal(t, "invalid config: sinks.include (INCLUDE_ENTITIES) contains unknown entities: accounts", err.Error())
+
+	// Очистка переменных окружения
+	os.Clearenv()
+	// Сброс флагов
+	flag.CommandLine = flag.NewFlagSet("", flag.ExitOnError)
+}
+
+func TestFromEnvEntities(t *testing.T) {
+	// Установка переменных окружения
+	os.Setenv("ZENMONEY_TOKEN", "test_token")
+	os.Setenv("CLICKHOUSE_USER", "test_user")
+	os.Setenv("CLICKHOUSE_DB", "test_db")
+	os.Setenv("CLICKHOUSE_PASSWORD", "test_password")
+	os.Setenv("INCLUDE_ENTITIES", "transaction, account,")
+	os.Setenv("EXCLUDE_ENTITIES", "account")
+
+	// Вызов функции FromEnv
+	cfg, err := FromEnv()
+	assert.NoError(t, err)
+
+	// Проверка, что списки сущностей прочитаны без пробелов и пустых имен, а фильтр из них строит пакет entity
+	assert.Equal(t, []string{"transaction", "account"}, cfg.IncludeEntities)
+	assert.Equal(t, []string{"account"}, cfg.ExcludeEntities)
+
+	// Очистка переменных окружения
+	os.Clearenv()
+	// Сброс флагов
+	flag.CommandLine = flag.NewFlagSet("", flag.ExitOnError)
+}
+
+func TestFromEnvConfigFile(t *testing.T) {
+	// Создание файла конфигурации
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `
+zenmoney:
+  token: file_token
+database:
+  clickhouse:
+    server: file_server
+    user: file_user
+    db: file_db
+    password: file_password
+schedule:
+  daemon: true
+  interval: 30
+sinks:
+  include: [transaction, account]
+`
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	// Переменные окружения имеют приоритет над файлом
+	os.Setenv("CONFIG_FILE", path)
+	os.Setenv("CLICKHOUSE_USER", "env_user")
+
+	// Вызов функции FromEnv
+	cfg, err := FromEnv()
+	assert.NoError(t, err)
+
+	// Проверка, что значения взяты из файла, кроме переопределенных окружением
+	assert.Equal(t, "file_token", cfg.ZenMoneyToken)
+	assert.Equal(t, "file_server", cfg.ClickhouseServer)
+	assert.Equal(t, "env_user", cfg.ClickhouseUser)
+	assert.Equal(t, "file_db", cfg.ClickhouseDB)
+	assert.Equal(t, true, cfg.IsDaemon)
+	assert.Equal(t, 30, cfg.Interval)
+	assert.Equal(t, []string{"transaction", "account"}, cfg.IncludeEntities)
+
+	// Очистка переменных окружения
+	os.Clearenv()
+	// Сброс флагов
+	flag.CommandLine = flag.NewFlagSet("", flag.ExitOnError)
+}
+
+func TestFromEnvReportsAllProblems(t *testing.T) {
+	// Создание файла конфигурации с неизвестным ключом и некорректными значениями
+	path := filepath.Join(t.TempDir(), "config.toml")
+	content := `
+[database]
+workers = 0
+unknown = 1
+
+[logging]
+level = "verbose"
+format = "xml"
+`
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	os.Setenv("CONFIG_FILE", path)
+
+	// Вызов функции FromEnv
+	cfg, err := FromEnv()
+	assert.Nil(t, cfg)
+
+	// Проверка, что все ошибки возвращены сразу с путями к настройкам
+	var validationErr *ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	fields := make([]string, len(validationErr.Problems))
+	for i, p := range validationErr.Problems {
+		fields[i] = p.Field
+	}
+	assert.Equal(t, []string{
+		"database.unknown",
+		"zenmoney.token",
+		"database.clickhouse.user",
+		"database.clickhouse.db",
+		"database.workers",
+		"logging.level",
+		"logging.format",
+	}, fields)
 
 	// Очистка переменных окружения
 	os.Clearenv()
 	// Сброс флагов
 	flag.CommandLine = flag.NewFlagSet("", flag.ExitOnError)
 }
+
+func TestFromEnvSecretFiles(t *testing.T) {
+	// Создание файлов с секретами, как их монтирует Docker
+	dir := t.TempDir()
+	tokenPath := filepath.Join(dir, "token")
+	passwordPath := filepath.Join(dir, "password")
+	assert.NoError(t, os.WriteFile(tokenPath, []byte("secret_token\n"), 0o600))
+	assert.NoError(t, os.WriteFile(passwordPath, []byte("secret_password"), 0o600))
+
+	// Установка переменных окружения
+	os.Setenv("ZENMONEY_TOKEN_FILE", tokenPath)
+	os.Setenv("CLICKHOUSE_PASSWORD_FILE", passwordPath)
+	os.Setenv("CLICKHOUSE_USER", "test_user")
+	os.Setenv("CLICKHOUSE_DB", "test_db")
+
+	// Вызов функции FromEnv
+	cfg, err := FromEnv()
+	assert.NoError(t, err)
+
+	// Проверка, что секреты прочитаны из файлов
+	assert.Equal(t, "secret_token", cfg.ZenMoneyToken)
+	assert.Equal(t, "secret_password", cfg.ClickhousePassword)
+
+	// Проверка, что при выводе конфигурации секреты скрыты
+	assert.NotContains(t, cfg.String(), "secret_token")
+	assert.NotContains(t, cfg.String(), "secret_password")
+	assert.Contains(t, cfg.String(), "ZENMONEY_TOKEN=[REDACTED]")
+
+	// Очистка переменных окружения
+	os.Clearenv()
+	// Сброс флагов
+	flag.CommandLine = flag.NewFlagSet("", flag.ExitOnError)
+}
+
+func TestFromEnvProfiles(t *testing.T) {
+	// Установка переменных окружения
+	os.Setenv("ZENMONEY_PROFILES", "alice=token_a, token_b")
+	os.Setenv("CLICKHOUSE_USER", "test_user")
+	os.Setenv("CLICKHOUSE_DB", "test_db")
+
+	// Вызов функции FromEnv
+	cfg, err := FromEnv()
+	assert.NoError(t, err)
+
+	// Проверка, что профили разобраны, а профиль без метки получил метку по умолчанию
+	assert.Equal(t, []Profile{
+		{Label: "alice", Token: "token_a"},
+		{Label: "profile2", Token: "token_b"},
+	}, cfg.Profiles())
+	assert.NotContains(t, cfg.String(), "token_a")
+
+	// Очистка переменных окружения
+	os.Clearenv()
+	// Сброс флагов
+	flag.CommandLine = flag.NewFlagSet("", flag.ExitOnError)
+}
+
+func TestFromEnvProfilesFromFile(t *testing.T) {
+	// Создание файла конфигурации с профилями
+	dir := t.TempDir()
+	tokenPath := filepath.Join(dir, "bob_token")
+	assert.NoError(t, os.WriteFile(tokenPath, []byte("token_b"), 0o600))
+	path := filepath.Join(dir, "config.yaml")
+	content := `
+zenmoney:
+  profiles:
+    - label: alice
+      token: token_a
+    - label: bob
+      token_file: ` + tokenPath + `
+    - label: alice
+      token: token_c
+`
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	os.Setenv("CONFIG_FILE", path)
+	os.Setenv("CLICKHOUSE_USER", "test_user")
+	os.Setenv("CLICKHOUSE_DB", "test_db")
+
+	// Вызов функции FromEnv
+	cfg, err := FromEnv()
+
+	// Проверка, что повторяющаяся метка профиля - ошибка
+	assert.Nil(t, cfg)
+	assert.Equal(t, `invalid config: zenmoney.profiles[2].label duplicates profile "alice"`, err.Error())
+
+	// Очистка переменных окружения
+	os.Clearenv()
+	// Сброс флагов
+	flag.CommandLine = flag.NewFlagSet("", flag.ExitOnError)
+}
+
+func TestFromEnvInvalidProfileLabel(t *testing.T) {
+	// Установка переменных окружения
+	os.Setenv("ZENMONEY_PROFILES", "../alice=token_a,bob=token_b")
+	os.Setenv("CLICKHOUSE_USER", "test_user")
+	os.Setenv("CLICKHOUSE_DB", "test_db")
+
+	// Вызов функции FromEnv
+	cfg, err := FromEnv()
+
+	// Проверка, что метка с разделителем пути - ошибка
+	assert.Nil(t, cfg)
+	assert.Equal(t, "invalid config: zenmoney.profiles[0].label must contain only letters, digits, _ and -", err.Error())
+
+	// Очистка переменных окружения
+	os.Clearenv()
+	// Сброс флагов
+	flag.CommandLine = flag.NewFlagSet("", flag.ExitOnError)
+}
+
+func TestFromEnvSingleTokenProfile(t *testing.T) {
+	// Установка переменных окружения
+	os.Setenv("ZENMONEY_TOKEN", "test_token")
+	os.Setenv("CLICKHOUSE_USER", "test_user")
+	os.Setenv("CLICKHOUSE_DB", "test_db")
+
+	// Вызов функции FromEnv
+	cfg, err := FromEnv()
+	assert.NoError(t, err)
+
+	// Проверка, что единственный токен становится профилем default
+	assert.Equal(t, []Profile{{Label: DefaultProfile, Token: "test_token"}}, cfg.Profiles())
+
+	// Очистка переменных окружения
+	os.Clearenv()
+	// Сброс флагов
+	flag.CommandLine = flag.NewFlagSet("", flag.ExitOnError)
+}
+
+func TestFromEnvDryRunInDaemon(t *testing.T) {
+	// Установка переменных окружения
+	os.Setenv("ZENMONEY_TOKEN", "test_token")
+	os.Setenv("CLICKHOUSE_USER", "test_user")
+	os.Setenv("CLICKHOUSE_DB", "test_db")
+	os.Setenv("IS_DAEMON", "true")
+	os.Setenv("DRY_RUN", "true")
+
+	// Вызов функции FromEnv
+	cfg, err := FromEnv()
+
+	// Проверка, что пробный запуск нельзя совместить с режимом демона
+	assert.Nil(t, cfg)
+	assert.Equal(t, "invalid config: sinks.dry_run (DRY_RUN) cannot be used in daemon mode", err.Error())
+
+	// Очистка переменных окружения
+	os.Clearenv()
+	// Сброс флагов
+	flag.CommandLine = flag.NewFlagSet("", flag.ExitOnError)
+}
+
+func TestFromEnvPeriod(t *testing.T) {
+	// Установка переменных окружения
+	os.Setenv("ZENMONEY_TOKEN", "test_token")
+	os.Setenv("CLICKHOUSE_USER", "test_user")
+	os.Setenv("CLICKHOUSE_DB", "test_db")
+	os.Setenv("SINCE", "2024-03-01")
+	os.Setenv("UNTIL", "2024-01-31")
+
+	// Вызов функции FromEnv
+	cfg, err := FromEnv()
+
+	// Проверка, что конец периода не может быть раньше начала
+	assert.Nil(t, cfg)
+	assert.Equal(t, "invalid config: sinks.until (UNTIL) must not be before since", err.Error())
+
+	os.Setenv("UNTIL", "31.01.2024")
+	_, err = FromEnv()
+	assert.Equal(t, "invalid config: sinks.until (UNTIL) must be a date in YYYY-MM-DD format", err.Error())
+
+	// Очистка переменных окружения
+	os.Clearenv()
+	// Сброс флагов
+	flag.CommandLine = flag.NewFlagSet("", flag.ExitOnError)
+}
+
+func TestFromEnvNotify(t *testing.T) {
+	// Установка переменных окружения
+	os.Setenv("ZENMONEY_TOKEN", "test_token")
+	os.Setenv("CLICKHOUSE_USER", "test_user")
+	os.Setenv("CLICKHOUSE_DB", "test_db")
+	os.Setenv("NOTIFY_WEBHOOK_URL", "hooks.example.com/zenexport")
+	os.Setenv("NOTIFY_ON", "never")
+	os.Setenv("NOTIFY_FAILURE_THRESHOLD", "0")
+
+	// Вызов функции FromEnv
+	cfg, err := FromEnv()
+
+	// Проверка, что все ошибки настроек уведомлений выводятся сразу
+	assert.Nil(t, cfg)
+	assert.Equal(t, "invalid config: notify.webhook_url (NOTIFY_WEBHOOK_URL) must be an http or https URL; "+
+		"notify.on (NOTIFY_ON) must be one of all, failure, alert; "+
+		"notify.failure_threshold (NOTIFY_FAILURE_THRESHOLD) must be at least 1", err.Error())
+
+	os.Setenv("NOTIFY_WEBHOOK_URL", "https://hooks.example.com/zenexport")
+	os.Setenv("NOTIFY_ON", "alert")
+	os.Setenv("NOTIFY_FAILURE_THRESHOLD", "5")
+	cfg, err = FromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, "alert", cfg.NotifyOn)
+	assert.Equal(t, 5, cfg.NotifyThreshold)
+
+	// Очистка переменных окружения
+	os.Clearenv()
+	// Сброс флагов
+	flag.CommandLine = flag.NewFlagSet("", flag.ExitOnError)
+}
+
+func TestFromEnvForecastDays(t *testing.T) {
+	// Установка переменных окружения
+	os.Setenv("ZENMONEY_TOKEN", "test_token")
+	os.Setenv("CLICKHOUSE_USER", "test_user")
+	os.Setenv("CLICKHOUSE_DB", "test_db")
+
+	// По умолчанию прогноз строится на 90 дней
+	cfg, err := FromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, 90, cfg.ForecastDays)
+
+	os.Setenv("FORECAST_DAYS", "-1")
+	cfg, err = FromEnv()
+	assert.Nil(t, cfg)
+	assert.Equal(t, "invalid config: sinks.forecast_days (FORECAST_DAYS) must be at least 1", err.Error())
+
+	// Очистка переменных окружения
+	os.Clearenv()
+	// Сброс флагов
+	flag.CommandLine = flag.NewFlagSet("", flag.ExitOnError)
+}
+
+func TestLoadFlagOverrides(t *testing.T) {
+	// Установка переменных окружения
+	os.Setenv("ZENMONEY_TOKEN", "env_token")
+	os.Setenv("CLICKHOUSE_USER", "test_user")
+	os.Setenv("CLICKHOUSE_DB", "test_db")
+	os.Setenv("WORKERS", "2")
+
+	fs := flag.NewFlagSet("sync", flag.ContinueOnError)
+	DefineFlags(fs)
+	DefineSyncFlags(fs)
+	assert.NoError(t, fs.Parse([]string{"-token", "flag_token", "-history", "-forecast-days", "30"}))
+
+	cfg, err := Load(fs)
+	assert.NoError(t, err)
+
+	// Заданные флаги переопределяют окружение, незаданные не меняют его
+	assert.Equal(t, "flag_token", cfg.ZenMoneyToken)
+	assert.True(t, cfg.History)
+	assert.Equal(t, 30, cfg.ForecastDays)
+	assert.Equal(t, 2, cfg.Workers)
+
+	// Очистка переменных окружения
+	os.Clearenv()
+}
+
+func TestLoadAsDaemon(t *testing.T) {
+	// Установка переменных окружения
+	os.Setenv("ZENMONEY_TOKEN", "test_token")
+	os.Setenv("CLICKHOUSE_USER", "test_user")
+	os.Setenv("CLICKHOUSE_DB", "test_db")
+	os.Setenv("INTERVAL", "0")
+
+	// Вызов функции Load в режиме демона
+	cfg, err := Load(nil, AsDaemon())
+
+	// Проверка, что настройки расписания проверяются при загрузке
+	assert.Nil(t, cfg)
+	assert.Equal(t, "invalid config: schedule.interval (INTERVAL) must be greater than zero", err.Error())
+
+	os.Setenv("INTERVAL", "5")
+	cfg, err = Load(nil, AsDaemon())
+	assert.NoError(t, err)
+	assert.True(t, cfg.IsDaemon)
+
+	// Очистка переменных окружения
+	os.Clearenv()
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"fmt"
+	"github.com/spf13/viper"
+	"sort"
+)
+
+// fileKeys сопоставляет ключи файла конфигурации с переменными окружения.
+// Файл разбит на секции, а значения из него имеют наименьший приоритет: файл < переменные окружения < флаги.
+var fileKeys = map[string]string{
+	"zenmoney.token":                    "ZENMONEY_TOKEN",
+	"zenmoney.token_file":               "ZENMONEY_TOKEN_FILE",
+	"zenmoney.profiles":                 "ZENMONEY_PROFILES",
+	"database.type":                     "DATABASE_TYPE",
+	"database.server":                   "DATABASE_SERVER",
+	"database.user":                     "DATABASE_USER",
+	"database.password":                 "DATABASE_PASSWORD",
+	"database.password_file":            "DATABASE_PASSWORD_FILE",
+	"database.name":                     "DATABASE_NAME",
+	"database.clickhouse.server":        "CLICKHOUSE_SERVER",
+	"database.clickhouse.user":          "CLICKHOUSE_USER",
+	"database.clickhouse.db":            "CLICKHOUSE_DB",
+	"database.clickhouse.password":      "CLICKHOUSE_PASSWORD",
+	"database.clickhouse.password_file": "CLICKHOUSE_PASSWORD_FILE",
+	"database.clickhouse.cluster":       "CLICKHOUSE_CLUSTER",
+	"database.batch_size":               "BATCH_SIZE",
+	"database.async_insert":             "ASYNC_INSERT",
+	"database.workers":                  "WORKERS",
+	"database.migrate":                  "MIGRATE",
+	"schedule.daemon":                   "IS_DAEMON",
+	"schedule.interval":                 "INTERVAL",
+	"sinks.include":                     "INCLUDE_ENTITIES",
+	"sinks.exclude":                     "EXCLUDE_ENTITIES",
+	"sinks.dry_run":                     "DRY_RUN",
+	"sinks.dry_run_report":              "DRY_RUN_REPORT",
+	"sinks.since":                       "SINCE",
+	"sinks.until":                       "UNTIL",
+	"sinks.history":                     "HISTORY",
+	"sinks.forecast_days":               "FORECAST_DAYS",
+	"logging.level":                     "LOG_LEVEL",
+	"logging.format":                    "LOG_FORMAT",
+	"logging.file":                      "LOG_FILE",
+	"tracing.exporter":                  "TRACING_EXPORTER",
+	"tracing.endpoint":                  "TRACING_ENDPOINT",
+	"notify.webhook_url":                "NOTIFY_WEBHOOK_URL",
+	"notify.command":                    "NOTIFY_COMMAND",
+	"notify.on":                         "NOTIFY_ON",
+	"notify.failure_threshold":          "NOTIFY_FAILURE_THRESHOLD",
+}
+
+// applyConfigFile читает YAML или TOML файл конфигурации (формат определяется по расширению)
+// и использует его значения вместо значений по умолчанию.
+// Возвращает список ошибок для неизвестных ключей файла.
+func applyConfigFile(v *viper.Viper, path string) ([]Problem, error) {
+	fv := viper.New()
+	fv.SetConfigFile(path)
+	if err := fv.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("error reading config file %s: %w", path, err)
+	}
+
+	keys := fv.AllKeys()
+	sort.Strings(keys)
+
+	var problems []Problem
+	for _, key := range keys {
+		envKey, ok := fileKeys[key]
+		if !ok {
+			problems = append(problems, Problem{Field: key, Message: "unknown setting"})
+			continue
+		}
+		v.SetDefault(envKey, fv.Get(key))
+	}
+	return problems, nil
+}
+
+// fieldEnv возвращает имя переменной окружения для ключа файла конфигурации.
+func fieldEnv(field string) string {
+	return fileKeys[field]
+}
+
+// fieldOf возвращает ключ файла конфигурации для переменной окружения.
+func fieldOf(env string) string {
+	for field, key := range fileKeys {
+		if key == env {
+			return field
+		}
+	}
+	return env
+}
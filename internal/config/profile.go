@@ -0,0 +1,122 @@
+package config
+
+import (
+	"fmt"
+	"github.com/mitchellh/mapstructure"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// DefaultProfile метка профиля, который используется, если задан только ZENMONEY_TOKEN.
+const DefaultProfile = "default"
+
+// profileLabel допустимая метка профиля. Метка используется в именах файлов выгрузки и партиций, поэтому
+// в ней нельзя использовать разделители путей.
+var profileLabel = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Profile описывает одну учетную запись ZenMoney. Все строки, сохраненные для профиля,
+// помечаются его меткой, поэтому несколько профилей можно выгружать в одну базу данных.
+type Profile struct {
+	Label     string `mapstructure:"label"`
+	Token     string `mapstructure:"token"`
+	TokenFile string `mapstructure:"token_file"`
+}
+
+// String возвращает метку профиля, чтобы токен не попадал в вывод.
+func (p Profile) String() string {
+	return p.Label
+}
+
+// Profiles возвращает список профилей для синхронизации. Если ZENMONEY_PROFILES не задан,
+// возвращается единственный профиль default с токеном из ZENMONEY_TOKEN.
+func (c Config) Profiles() []Profile {
+	if len(c.ZenMoneyProfiles) > 0 {
+		return c.ZenMoneyProfiles
+	}
+	if c.ZenMoneyToken == "" {
+		return nil
+	}
+	return []Profile{{Label: DefaultProfile, Token: c.ZenMoneyToken}}
+}
+
+// stringToProfilesHook разбирает ZENMONEY_PROFILES из переменной окружения или флага в формате
+// "label=token,label=token". Метку можно не указывать: "token,token".
+func stringToProfilesHook(from reflect.Type, to reflect.Type, data interface{}) (interface{}, error) {
+	if from.Kind() != reflect.String || to != reflect.TypeOf([]Profile{}) {
+		return data, nil
+	}
+
+	var profiles []Profile
+	for _, item := range strings.Split(data.(string), ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		label, token, found := strings.Cut(item, "=")
+		if !found {
+			label, token = "", label
+		}
+		profiles = append(profiles, Profile{Label: strings.TrimSpace(label), Token: strings.TrimSpace(token)})
+	}
+	return profiles, nil
+}
+
+// decodeHook объединяет стандартные преобразования viper с разбором профилей.
+// Профили разбираются первыми, иначе строка будет разбита на []string.
+func decodeHook() mapstructure.DecodeHookFunc {
+	return mapstructure.ComposeDecodeHookFunc(
+		stringToProfilesHook,
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+	)
+}
+
+// resolveProfiles заполняет метки по умолчанию и читает токены из token_file.
+// Первый профиль без метки получает метку default, остальные - profile<N>.
+func (c *Config) resolveProfiles() error {
+	for i := range c.ZenMoneyProfiles {
+		p := &c.ZenMoneyProfiles[i]
+		if p.Label == "" {
+			p.Label = DefaultProfile
+			if i > 0 {
+				p.Label = fmt.Sprintf("profile%d", i+1)
+			}
+		}
+		if p.TokenFile != "" && p.Token == "" {
+			content, err := os.ReadFile(p.TokenFile)
+			if err != nil {
+				return fmt.Errorf("error reading token_file of profile %s: %w", p.Label, err)
+			}
+			p.Token = strings.TrimSpace(string(content))
+		}
+	}
+	return nil
+}
+
+// validateProfiles проверяет, что задан хотя бы один токен, а метки профилей допустимы и уникальны.
+func (c Config) validateProfiles() []Problem {
+	if len(c.ZenMoneyProfiles) == 0 {
+		if c.ZenMoneyToken == "" {
+			return []Problem{{Field: "zenmoney.token", Message: "is required"}}
+		}
+		return nil
+	}
+
+	var problems []Problem
+	seen := make(map[string]bool, len(c.ZenMoneyProfiles))
+	for i, p := range c.ZenMoneyProfiles {
+		field := fmt.Sprintf("zenmoney.profiles[%d]", i)
+		if p.Token == "" {
+			problems = append(problems, Problem{Field: field + ".token", Message: "is required"})
+		}
+		if !profileLabel.MatchString(p.Label) {
+			problems = append(problems, Problem{Field: field + ".label", Message: "must contain only letters, digits, _ and -"})
+		} else if seen[p.Label] {
+			problems = append(problems, Problem{Field: field + ".label", Message: fmt.Sprintf("duplicates profile %q", p.Label)})
+		}
+		seen[p.Label] = true
+	}
+	return problems
+}
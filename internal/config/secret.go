@@ -0,0 +1,86 @@
+package config
+
+import (
+	"fmt"
+	"github.com/spf13/viper"
+	"log/slog"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// redacted заменяет значения секретов при выводе конфигурации.
+const redacted = "[REDACTED]"
+
+// secretKeys настройки с секретами. Для каждой из них можно указать путь к файлу в переменной <KEY>_FILE,
+// например ZENMONEY_TOKEN_FILE, что совместимо с секретами Docker и Kubernetes.
+var secretKeys = []string{"ZENMONEY_TOKEN", "DATABASE_PASSWORD", "CLICKHOUSE_PASSWORD"}
+
+// applySecretFiles читает секреты из файлов, указанных в <KEY>_FILE. Значение из файла заменяет значение
+// из файла конфигурации, но не переменную окружения или флаг. Одновременное указание <KEY> и <KEY>_FILE
+// в окружении считается ошибкой.
+func applySecretFiles(v *viper.Viper) ([]Problem, error) {
+	var problems []Problem
+	for _, key := range secretKeys {
+		fileKey := key + "_FILE"
+		path := v.GetString(fileKey)
+		if path == "" {
+			continue
+		}
+
+		if os.Getenv(key) != "" {
+			problems = append(problems, Problem{
+				Field:   fieldOf(fileKey),
+				Message: fmt.Sprintf("cannot be used together with %s", key),
+			})
+			continue
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %w", fileKey, err)
+		}
+		v.SetDefault(key, strings.TrimSpace(string(content)))
+	}
+	return problems, nil
+}
+
+// isSecret проверяет, содержит ли настройка с именем переменной окружения key секрет.
+func isSecret(key string) bool {
+	for _, secret := range secretKeys {
+		if key == secret {
+			return true
+		}
+	}
+	return false
+}
+
+// LogValue реализует slog.LogValuer, чтобы при логировании конфигурации секреты не попадали в лог.
+func (c Config) LogValue() slog.Value {
+	value := reflect.ValueOf(c)
+	attrs := make([]slog.Attr, 0, value.NumField())
+	for i := 0; i < value.NumField(); i++ {
+		key := value.Type().Field(i).Tag.Get("mapstructure")
+		field := value.Field(i)
+		if profiles, ok := field.Interface().([]Profile); ok {
+			// Для профилей выводятся только метки
+			labels := make([]string, len(profiles))
+			for i, p := range profiles {
+				labels[i] = p.Label
+			}
+			attrs = append(attrs, slog.Any(key, labels))
+			continue
+		}
+		if isSecret(key) && !field.IsZero() {
+			attrs = append(attrs, slog.String(key, redacted))
+			continue
+		}
+		attrs = append(attrs, slog.Any(key, field.Interface()))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// String возвращает конфигурацию в виде строки со скрытыми секретами.
+func (c Config) String() string {
+	return c.LogValue().String()
+}
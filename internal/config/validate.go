@@ -0,0 +1,149 @@
+package config
+
+import (
+	"fmt"
+	"github.com/nemirlev/zenexport/internal/entity/names"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Problem описывает одну ошибку конфигурации.
+type Problem struct {
+	// Field путь к настройке в файле конфигурации, например database.clickhouse.user.
+	Field   string
+	Message string
+}
+
+func (p Problem) String() string {
+	if env := fieldEnv(p.Field); env != "" {
+		return fmt.Sprintf("%s (%s) %s", p.Field, env, p.Message)
+	}
+	return fmt.Sprintf("%s %s", p.Field, p.Message)
+}
+
+// ValidationError содержит все ошибки, найденные при проверке конфигурации.
+type ValidationError struct {
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	problems := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		problems[i] = p.String()
+	}
+	return "invalid config: " + strings.Join(problems, "; ")
+}
+
+// logLevels допустимые значения LOG_LEVEL.
+var logLevels = []string{"debug", "info", "warn", "error"}
+
+// logFormats допустимые значения LOG_FORMAT.
+var logFormats = []string{"json", "text", "pretty"}
+
+// tracingExporters допустимые значения TRACING_EXPORTER.
+var tracingExporters = []string{"none", "otlp", "stdout"}
+
+// notifyModes допустимые значения NOTIFY_ON.
+var notifyModes = []string{"all", "failure", "alert"}
+
+// Validate проверяет конфигурацию и возвращает *ValidationError со всеми найденными ошибками сразу.
+// Настройки базы данных проверяются схемой бэкенда, зарегистрированной через RegisterBackend.
+func (c Config) Validate() error {
+	if problems := c.validate(options{}); len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// validate возвращает ошибки конфигурации с учетом опций загрузки.
+func (c Config) validate(o options) []Problem {
+	var problems []Problem
+	add := func(field, message string) {
+		problems = append(problems, Problem{Field: field, Message: message})
+	}
+
+	if !o.skipToken {
+		problems = append(problems, c.validateProfiles()...)
+	}
+	if !o.skipDatabase {
+		problems = append(problems, c.validateBackend()...)
+	}
+	if c.IsDaemon && c.Interval <= 0 {
+		add("schedule.interval", "must be greater than zero")
+	}
+	if c.IsDaemon && c.DryRun {
+		add("sinks.dry_run", "cannot be used in daemon mode")
+	}
+	if c.DryRunReport != "" && !c.DryRun {
+		add("sinks.dry_run_report", "requires dry run")
+	}
+	since, sinceErr := parseDate(c.Since)
+	if sinceErr != nil {
+		add("sinks.since", "must be a date in YYYY-MM-DD format")
+	}
+	until, untilErr := parseDate(c.Until)
+	if untilErr != nil {
+		add("sinks.until", "must be a date in YYYY-MM-DD format")
+	}
+	if !since.IsZero() && !until.IsZero() && until.Before(since) {
+		add("sinks.until", "must not be before since")
+	}
+	if c.BatchSize < 0 {
+		add("database.batch_size", "must not be negative")
+	}
+	if c.Workers < 1 {
+		add("database.workers", "must be at least 1")
+	}
+	if unknown := names.Unknown(c.IncludeEntities); len(unknown) > 0 {
+		add("sinks.include", "contains unknown entities: "+strings.Join(unknown, ", "))
+	}
+	if unknown := names.Unknown(c.ExcludeEntities); len(unknown) > 0 {
+		add("sinks.exclude", "contains unknown entities: "+strings.Join(unknown, ", "))
+	}
+	if c.ForecastDays < 1 {
+		add("sinks.forecast_days", "must be at least 1")
+	}
+	if !contains(logLevels, c.LogLevel) {
+		add("logging.level", fmt.Sprintf("must be one of %s", strings.Join(logLevels, ", ")))
+	}
+	if !contains(logFormats, c.LogFormat) {
+		add("logging.format", fmt.Sprintf("must be one of %s", strings.Join(logFormats, ", ")))
+	}
+	if !contains(tracingExporters, c.TracingExporter) {
+		add("tracing.exporter", fmt.Sprintf("must be one of %s", strings.Join(tracingExporters, ", ")))
+	}
+	if c.TracingEndpoint != "" && c.TracingExporter != "otlp" {
+		add("tracing.endpoint", "requires otlp exporter")
+	}
+	if c.NotifyWebhookURL != "" {
+		if u, err := url.Parse(c.NotifyWebhookURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			add("notify.webhook_url", "must be an http or https URL")
+		}
+	}
+	if !contains(notifyModes, c.NotifyOn) {
+		add("notify.on", fmt.Sprintf("must be one of %s", strings.Join(notifyModes, ", ")))
+	}
+	if c.NotifyThreshold < 1 {
+		add("notify.failure_threshold", "must be at least 1")
+	}
+
+	return problems
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// parseDate разбирает дату SINCE/UNTIL в формате YYYY-MM-DD. Пустая строка дает нулевую дату.
+func parseDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
@@ -0,0 +1,114 @@
+// Package currency пересчитывает суммы между валютами ДзенМани по курсам инструментов.
+// Курс инструмента в ДзенМани - стоимость единицы валюты в рублях, поэтому сумма переводится через рубль.
+package currency
+
+import (
+	"fmt"
+	"github.com/nemirlev/zenapi"
+	"time"
+)
+
+// Converter пересчитывает суммы по курсам инструментов и знает основную валюту каждого пользователя.
+// Если задана история курсов, суммы на дату пересчитываются по курсу, ближайшему к этой дате.
+type Converter struct {
+	rates      map[int]float64
+	currencies map[int]int
+	history    *History
+}
+
+// New создает конвертер по инструментам и пользователям из ответа ZenMoney.
+func New(data *zenapi.Response) *Converter {
+	c := &Converter{
+		rates:      make(map[int]float64, len(data.Instrument)),
+		currencies: make(map[int]int, len(data.User)),
+	}
+	for _, instrument := range data.Instrument {
+		c.rates[instrument.ID] = instrument.Rate
+	}
+	for _, user := range data.User {
+		c.currencies[user.ID] = user.Currency
+	}
+	return c
+}
+
+// WithHistory задает историю курсов для пересчета сумм на дату и возвращает конвертер.
+func (c *Converter) WithHistory(history *History) *Converter {
+	c.history = history
+	return c
+}
+
+// Rate возвращает текущий курс инструмента. Инструменты без курса или с нулевым курсом считаются неизвестными.
+func (c *Converter) Rate(instrument int) (float64, error) {
+	rate, ok := c.rates[instrument]
+	if !ok || rate <= 0 {
+		return 0, fmt.Errorf("no rate for instrument %d", instrument)
+	}
+	return rate, nil
+}
+
+// RateAt возвращает курс инструмента из истории, ближайший к моменту at, а если истории курсов
+// инструмента нет или at не задан - текущий курс.
+func (c *Converter) RateAt(instrument int, at time.Time) (float64, error) {
+	if !at.IsZero() {
+		if rate, ok := c.history.RateAt(instrument, at); ok {
+			return rate, nil
+		}
+	}
+	return c.Rate(instrument)
+}
+
+// Convert переводит сумму amount из валюты from в валюту to по текущим курсам.
+func (c *Converter) Convert(amount float64, from, to int) (float64, error) {
+	return c.ConvertAt(amount, from, to, time.Time{})
+}
+
+// ConvertAt переводит сумму amount из валюты from в валюту to по курсам, ближайшим к моменту at.
+func (c *Converter) ConvertAt(amount float64, from, to int, at time.Time) (float64, error) {
+	if from == to || amount == 0 {
+		return amount, nil
+	}
+	fromRate, err := c.RateAt(from, at)
+	if err != nil {
+		return 0, err
+	}
+	toRate, err := c.RateAt(to, at)
+	if err != nil {
+		return 0, err
+	}
+	return amount * fromRate / toRate, nil
+}
+
+// UserCurrency возвращает основную валюту пользователя.
+func (c *Converter) UserCurrency(user int) (int, error) {
+	currency, ok := c.currencies[user]
+	if !ok {
+		return 0, fmt.Errorf("unknown user %d", user)
+	}
+	return currency, nil
+}
+
+// Amounts суммы транзакции в основной валюте пользователя.
+type Amounts struct {
+	Currency int
+	Income   float64
+	Outcome  float64
+}
+
+// Transaction переводит доход и расход транзакции в основную валюту ее пользователя по курсам на дату транзакции.
+func (c *Converter) Transaction(t zenapi.Transaction) (Amounts, error) {
+	currency, err := c.UserCurrency(t.User)
+	if err != nil {
+		return Amounts{}, err
+	}
+	// Если дата не разобралась, используются текущие курсы
+	date, _ := time.Parse(time.DateOnly, t.Date)
+	income, err := c.ConvertAt(t.Income, t.IncomeInstrument, currency, date)
+	if err != nil {
+		return Amounts{}, err
+	}
+	outcome, err := c.ConvertAt(t.Outcome, t.OutcomeInstrument, currency, date)
+	if err != nil {
+		return Amounts{}, err
+	}
+	return Amounts{Currency: currency, Income: income, Outcome: outcome}, nil
+}
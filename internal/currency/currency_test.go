@@ -0,0 +1,110 @@
+package currency
+
+import (
+	"github.com/nemirlev/zenapi"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+const (
+	rub = 2
+	usd = 1
+	eur = 3
+)
+
+func newData() *zenapi.Response {
+	return &zenapi.Response{
+		Instrument: []zenapi.Instrument{
+			{ID: usd, Rate: 90},
+			{ID: rub, Rate: 1},
+			{ID: eur, Rate: 100},
+			{ID: 4},
+		},
+		User: []zenapi.User{{ID: 10, Currency: rub}, {ID: 11, Currency: usd}},
+	}
+}
+
+// Тестируем пересчет через рубль и ошибки для инструментов без курса
+func TestConvert(t *testing.T) {
+	c := New(newData())
+
+	amount, err := c.Convert(10, usd, rub)
+	assert.NoError(t, err)
+	assert.Equal(t, 900.0, amount)
+
+	amount, err = c.Convert(90, eur, usd)
+	assert.NoError(t, err)
+	assert.Equal(t, 100.0, amount)
+
+	amount, err = c.Convert(5, 4, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, 5.0, amount)
+
+	_, err = c.Convert(5, 4, rub)
+	assert.EqualError(t, err, "no rate for instrument 4")
+	_, err = c.Convert(5, rub, 99)
+	assert.EqualError(t, err, "no rate for instrument 99")
+}
+
+// Тестируем перевод сумм транзакции в валюту пользователя
+func TestTransaction(t *testing.T) {
+	c := New(newData())
+
+	amounts, err := c.Transaction(zenapi.Transaction{
+		User:              11,
+		IncomeInstrument:  usd,
+		Income:            0,
+		OutcomeInstrument: rub,
+		Outcome:           450,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, Amounts{Currency: usd, Income: 0, Outcome: 5}, amounts)
+
+	_, err = c.Transaction(zenapi.Transaction{User: 12})
+	assert.EqualError(t, err, "unknown user 12")
+}
+
+// Тестируем выбор ближайшего к дате курса из истории
+func TestHistoryRateAt(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	h := NewHistory([]RatePoint{
+		{Instrument: usd, Rate: 95, ObservedAt: day(20)},
+		{Instrument: usd, Rate: 90, ObservedAt: day(10)},
+		{Instrument: usd, Rate: 0, ObservedAt: day(14)},
+	})
+
+	for _, tt := range []struct {
+		at   time.Time
+		rate float64
+	}{
+		{day(1), 90},
+		{day(14), 90},
+		{day(15), 90},
+		{day(16), 95},
+		{day(31), 95},
+	} {
+		rate, ok := h.RateAt(usd, tt.at)
+		assert.True(t, ok)
+		assert.Equal(t, tt.rate, rate, tt.at)
+	}
+
+	_, ok := h.RateAt(eur, day(1))
+	assert.False(t, ok)
+}
+
+// Тестируем пересчет транзакции по курсу на ее дату с откатом на текущий курс
+func TestTransactionWithHistory(t *testing.T) {
+	c := New(newData()).WithHistory(NewHistory([]RatePoint{
+		{Instrument: usd, Rate: 80, ObservedAt: time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC)},
+	}))
+
+	amounts, err := c.Transaction(zenapi.Transaction{User: 10, OutcomeInstrument: usd, Outcome: 10, Date: "2024-01-11"})
+	assert.NoError(t, err)
+	assert.Equal(t, 800.0, amounts.Outcome)
+
+	// Для евро истории нет, используется текущий курс
+	amounts, err = c.Transaction(zenapi.Transaction{User: 10, OutcomeInstrument: eur, Outcome: 10, Date: "2024-01-11"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1000.0, amounts.Outcome)
+}
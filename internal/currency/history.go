@@ -0,0 +1,58 @@
+package currency
+
+import (
+	"sort"
+	"time"
+)
+
+// RatePoint курс инструмента, наблюдавшийся в момент ObservedAt.
+type RatePoint struct {
+	Instrument int
+	Rate       float64
+	ObservedAt time.Time
+}
+
+// History история курсов инструментов.
+type History struct {
+	points map[int][]RatePoint
+}
+
+// NewHistory создает историю курсов. Точки с нулевым курсом пропускаются, порядок точек не важен.
+func NewHistory(points []RatePoint) *History {
+	h := &History{points: make(map[int][]RatePoint)}
+	for _, p := range points {
+		if p.Rate <= 0 {
+			continue
+		}
+		h.points[p.Instrument] = append(h.points[p.Instrument], p)
+	}
+	for _, series := range h.points {
+		sort.Slice(series, func(i, j int) bool { return series[i].ObservedAt.Before(series[j].ObservedAt) })
+	}
+	return h
+}
+
+// RateAt возвращает курс инструмента, наблюдавшийся ближе всего к моменту at. При равном расстоянии
+// выбирается более ранний курс. Возвращает false, если курсов инструмента в истории нет.
+func (h *History) RateAt(instrument int, at time.Time) (float64, bool) {
+	if h == nil {
+		return 0, false
+	}
+	series := h.points[instrument]
+	if len(series) == 0 {
+		return 0, false
+	}
+
+	i := sort.Search(len(series), func(i int) bool { return !series[i].ObservedAt.Before(at) })
+	switch {
+	case i == 0:
+		return series[0].Rate, true
+	case i == len(series):
+		return series[i-1].Rate, true
+	}
+	before, after := series[i-1], series[i]
+	if at.Sub(before.ObservedAt) <= after.ObservedAt.Sub(at) {
+		return before.Rate, true
+	}
+	return after.Rate, true
+}
@@ -0,0 +1,116 @@
+package clickhouse
+
+import (
+	"context"
+	"github.com/nemirlev/zenapi"
+	"github.com/nemirlev/zenexport/internal/audit"
+	"github.com/nemirlev/zenexport/internal/entity"
+	"github.com/nemirlev/zenexport/internal/logger"
+	"time"
+)
+
+// auditTable таблица изменений полей транзакций.
+const auditTable = "transaction_audit"
+
+// auditedTransactions возвращает сохраненные транзакции профиля, которые изменились или пропали в ZenMoney.
+// incoming - все транзакции из ZenMoney, без ограничения периодом. Учитываются только транзакции, которые
+// перезаписываются при сохранении: сохраненные или полученные за период period. Сохраненные строки ищутся
+// по идентификатору без учета периода, поэтому перенос даты за границу периода записывается как изменение,
+// а удаленной считается только транзакция, которой нет в ответе ZenMoney. Сначала сравниваются только версии,
+// поэтому полностью читаются лишь измененные строки. Вызывается до перезаписи таблицы transaction.
+func (s *Store) auditedTransactions(ctx context.Context, profile string, incoming []zenapi.Transaction, period entity.Period) ([]zenapi.Transaction, error) {
+	conn, err := s.conn()
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := s.tableVersions(queryContext(ctx), entity.Transaction, profile, entity.Period{})
+	if err != nil {
+		return nil, err
+	}
+	storedInPeriod := stored
+	if !period.IsZero() {
+		if storedInPeriod, err = s.tableVersions(queryContext(ctx), entity.Transaction, profile, period); err != nil {
+			return nil, err
+		}
+	}
+	incomingInPeriod := make(map[string]bool, len(incoming))
+	for _, t := range incoming {
+		if period.Contains(t.Date) {
+			incomingInPeriod[t.ID] = true
+		}
+	}
+
+	changes := entity.Diff(stored, entity.Versions(&zenapi.Response{Transaction: incoming})[entity.Transaction])
+	var ids []string
+	for _, id := range changes.Changed {
+		if _, ok := storedInPeriod[id]; ok || incomingInPeriod[id] {
+			ids = append(ids, id)
+		}
+	}
+	for _, id := range changes.Removed {
+		if _, ok := storedInPeriod[id]; ok {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	query := `
+		SELECT toString(id), changed, user, deleted, hold, income_instrument, income_account, income,
+			outcome_instrument, outcome_account, outcome, arrayMap(x -> toString(x), tag), toString(merchant),
+			payee, comment, date
+		FROM transaction
+		WHERE profile = ? AND has(?, toString(id))
+	`
+	rows, err := conn.Query(queryContext(ctx), query, profile, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transactions []zenapi.Transaction
+	for rows.Next() {
+		var (
+			t                                                  zenapi.Transaction
+			changed, user, incomeInstrument, outcomeInstrument int32
+		)
+		err := rows.Scan(&t.ID, &changed, &user, &t.Deleted, &t.Hold, &incomeInstrument, &t.IncomeAccount, &t.Income,
+			&outcomeInstrument, &t.OutcomeAccount, &t.Outcome, &t.Tag, &t.Merchant, &t.Payee, &t.Comment, &t.Date)
+		if err != nil {
+			return nil, err
+		}
+		t.Changed, t.User = int(changed), int(user)
+		t.IncomeInstrument, t.OutcomeInstrument = int(incomeInstrument), int(outcomeInstrument)
+		transactions = append(transactions, t)
+	}
+	return transactions, rows.Err()
+}
+
+// saveAudit дописывает изменения полей транзакций в таблицу transaction_audit. Записи аудита не перезаписываются.
+func (s *Store) saveAudit(ctx context.Context, profile string, changes []audit.Change, observedAt time.Time) error {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO transaction_audit (
+			transaction_id, change_type, field, old_value, new_value, changed_at, observed_at, profile
+		) VALUES (
+			?, ?, ?, ?, ?, ?, ?, ?
+		)
+	`
+
+	start := time.Now()
+	err := s.executeBatch(ctx, auditTable, query, len(changes), func(i int) []interface{} {
+		c := changes[i]
+		return []interface{}{c.TransactionID, c.Type, c.Field, c.OldValue, c.NewValue, c.ChangedAt, observedAt, profile}
+	})
+	if err != nil {
+		s.Log.WithErrorContext(ctx, err, "failed to save transaction audit", logger.Table, auditTable)
+		return err
+	}
+	s.Log.InfoContext(ctx, "saved table", logger.Table, auditTable, logger.Rows, len(changes), logger.Duration, time.Since(start))
+	return nil
+}
@@ -7,81 +7,431 @@ import (
 	"github.com/ClickHouse/clickhouse-go/v2"
 	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
 	"github.com/nemirlev/zenexport/internal/config"
+	"github.com/nemirlev/zenexport/internal/entity"
 	"github.com/nemirlev/zenexport/internal/logger"
+	"strings"
+	"sync"
+	"time"
+)
+
+// localSuffix суффикс локальных (ReplicatedMergeTree) таблиц в режиме кластера.
+// Distributed-таблицы носят исходные имена, поэтому запросы на вставку не меняются.
+const localSuffix = "_local"
+
+// Параметры пула соединений. Пул создается один раз в Open и живет все время работы демона.
+const (
+	maxOpenConns      = 10
+	maxIdleConns      = 5
+	connMaxLifetime   = time.Hour
+	reconnectAttempts = 3
+	reconnectDelay    = 2 * time.Second
 )
 
 type Store struct {
 	Conn   driver.Conn
 	Log    logger.Log
 	Config *config.Config
+
+	mu sync.Mutex
 }
 
-// connect устанавливает соединение с базой данных ClickHouse, используя параметры, указанные в конфигурации.
-// Параметры:
-// - s: указатель на структуру Store, содержащую конфигурацию и логгер.
-func (s *Store) connect() error {
-	var (
-		ctx       = context.Background()
-		conn, err = clickhouse.Open(&clickhouse.Options{
-			Addr: []string{fmt.Sprintf("%s:9000", s.Config.ClickhouseServer)},
-			Auth: clickhouse.Auth{
-				Database: s.Config.ClickhouseDB,
-				Username: s.Config.ClickhouseUser,
-				Password: s.Config.ClickhousePassword,
-			},
-			Debugf: func(format string, v ...interface{}) {
-				s.Log.Debug(format, v)
-			},
-		})
-	)
+// Open открывает пул соединений с ClickHouse. Повторный вызов при открытом пуле ничего не делает.
+func (s *Store) Open(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 
+	if s.Conn != nil {
+		return nil
+	}
+	conn, err := s.dial(ctx)
 	if err != nil {
 		return err
 	}
+	s.Conn = conn
+	return nil
+}
+
+// Close закрывает пул соединений. После закрытия хранилище можно снова открыть через Open.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.Conn == nil {
+		return nil
+	}
+	err := s.Conn.Close()
+	s.Conn = nil
+	return err
+}
+
+// Ping проверяет доступность ClickHouse через открытый пул соединений.
+func (s *Store) Ping(ctx context.Context) error {
+	conn, err := s.conn()
+	if err != nil {
+		return err
+	}
+	return conn.Ping(ctx)
+}
+
+// errNotConnected возвращается, если пул соединений закрыт или сброшен неудачным переподключением.
+var errNotConnected = errors.New("clickhouse connection is not open")
+
+// conn возвращает текущий пул соединений. Блокировка удерживается только на время чтения s.Conn,
+// чтобы сетевые вызовы не задерживали Close и параллельные задачи. Все запросы получают пул через conn,
+// а не читают s.Conn напрямую, так как Close и переподключение заменяют его.
+func (s *Store) conn() (driver.Conn, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Conn == nil {
+		return nil, errNotConnected
+	}
+	return s.Conn, nil
+}
+
+// ensureConnection проверяет соединение перед работой с данными и при его потере переподключается,
+// делая несколько попыток с увеличивающейся паузой. Во время проверки, подключения и паузы блокировка
+// не удерживается, она берется только для замены s.Conn.
+func (s *Store) ensureConnection(ctx context.Context) error {
+	if conn, err := s.conn(); err == nil {
+		err := conn.Ping(ctx)
+		if err == nil {
+			return nil
+		}
+		s.Log.WithErrorContext(ctx, err, "clickhouse connection lost, reconnecting")
+
+		s.mu.Lock()
+		// Пул мог уже заменить другой вызов, тогда закрывать нечего
+		if s.Conn == conn {
+			if err := s.Conn.Close(); err != nil {
+				s.Log.WithError(err, "failed to close connection")
+			}
+			s.Conn = nil
+		}
+		s.mu.Unlock()
+	}
+
+	var err error
+	for attempt := 1; attempt <= reconnectAttempts; attempt++ {
+		var conn driver.Conn
+		if conn, err = s.dial(ctx); err == nil {
+			s.mu.Lock()
+			// Если другой вызов уже переподключился, используется его пул
+			if s.Conn == nil {
+				s.Conn, conn = conn, nil
+			}
+			s.mu.Unlock()
+			if conn != nil {
+				_ = conn.Close()
+			}
+			return nil
+		}
+		s.Log.WithErrorContext(ctx, err, "failed to connect to clickhouse", "attempt", attempt)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(attempt) * reconnectDelay):
+		}
+	}
+	return err
+}
+
+// dial открывает новый пул соединений с ClickHouse по параметрам из конфигурации и проверяет его.
+// Параметры:
+// - ctx: контекст для управления временем выполнения проверки соединения.
+func (s *Store) dial(ctx context.Context) (driver.Conn, error) {
+	conn, err := clickhouse.Open(&clickhouse.Options{
+		Addr: []string{fmt.Sprintf("%s:9000", s.Config.ClickhouseServer)},
+		Auth: clickhouse.Auth{
+			Database: s.Config.ClickhouseDB,
+			Username: s.Config.ClickhouseUser,
+			Password: s.Config.ClickhousePassword,
+		},
+		Settings:        s.settings(),
+		MaxOpenConns:    maxOpenConns,
+		MaxIdleConns:    maxIdleConns,
+		ConnMaxLifetime: connMaxLifetime,
+		Debugf: func(format string, v ...interface{}) {
+			s.Log.Debug(fmt.Sprintf(format, v...))
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	if err := conn.Ping(ctx); err != nil {
 		var exception *clickhouse.Exception
 		if errors.As(err, &exception) {
-			s.Log.WithError(err, "Exception [%d] %s \n%s\n", exception.Code, exception.Message, exception.StackTrace)
+			s.Log.WithErrorContext(ctx, err, "clickhouse exception", "code", exception.Code, "message", exception.Message)
 		}
+		_ = conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// filter возвращает фильтр сущностей INCLUDE_ENTITIES/EXCLUDE_ENTITIES из конфигурации.
+func (s *Store) filter() (entity.Filter, error) {
+	return entity.NewFilter(s.Config.IncludeEntities, s.Config.ExcludeEntities)
+}
+
+// period возвращает период SINCE/UNTIL, которым ограничиваются бюджеты, отметки напоминаний и транзакции.
+func (s *Store) period() (entity.Period, error) {
+	return entity.ParsePeriod(s.Config.Since, s.Config.Until)
+}
+
+// isCluster возвращает true, если задано имя кластера ClickHouse.
+func (s *Store) isCluster() bool {
+	return s.Config.ClickhouseCluster != ""
+}
+
+// settings возвращает настройки сессии ClickHouse. В режиме кластера вставка в Distributed-таблицу
+// выполняется синхронно, чтобы данные были доступны на всех шардах сразу после завершения batch.
+func (s *Store) settings() clickhouse.Settings {
+	if !s.isCluster() {
+		return nil
+	}
+	return clickhouse.Settings{
+		"insert_distributed_sync": 1,
+	}
+}
+
+// localTable возвращает имя таблицы, в которой физически хранятся данные.
+// В режиме кластера это локальная реплицируемая таблица, иначе - сама таблица.
+func (s *Store) localTable(tableName string) string {
+	if !s.isCluster() {
+		return tableName
+	}
+	return tableName + localSuffix
+}
+
+// onCluster возвращает выражение ON CLUSTER для DDL-запросов или пустую строку вне режима кластера.
+// Имя кластера экранируется как идентификатор, поэтому допустимы имена вроде my-cluster.
+func (s *Store) onCluster() string {
+	if !s.isCluster() {
+		return ""
+	}
+	return " ON CLUSTER " + quoteIdentifier(s.Config.ClickhouseCluster)
+}
+
+// quoteIdentifier заключает идентификатор ClickHouse в обратные кавычки.
+func quoteIdentifier(name string) string {
+	return "`" + strings.NewReplacer(`\`, `\\`, "`", "\\`").Replace(name) + "`"
+}
+
+// quoteString записывает строку как строковый литерал ClickHouse.
+func quoteString(value string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(value) + "'"
+}
+
+// rowFunc строит строку для вставки из i-го элемента исходных данных.
+// Строки формируются по мере отправки, поэтому в памяти одновременно находится не больше одного чанка.
+type rowFunc func(i int) []interface{}
+
+// insertContext возвращает контекст с настройками асинхронной вставки, если она включена в конфигурации.
+// Сервер дожидается записи буфера, чтобы ошибки вставки не терялись.
+func (s *Store) insertContext(ctx context.Context) context.Context {
+	if !s.Config.AsyncInsert {
+		return ctx
+	}
+	return clickhouse.Context(ctx, clickhouse.WithSettings(clickhouse.Settings{
+		"async_insert":          1,
+		"wait_for_async_insert": 1,
+	}))
+}
+
+// executeBatch выполняет пакетный запрос в ClickHouse, отправляя данные чанками по Config.BatchSize строк.
+// После отправки каждого чанка выводится прогресс.
+// Параметры:
+// - ctx: контекст для управления временем выполнения и отменой запроса.
+// - tableName: имя таблицы, используется для вывода прогресса.
+// - query: строка с SQL-запросом для выполнения пакетного вставки данных.
+// - total: общее количество строк.
+// - row: функция, возвращающая строку по ее индексу.
+func (s *Store) executeBatch(ctx context.Context, tableName string, query string, total int, row rowFunc) error {
+	chunkSize := s.Config.BatchSize
+	if chunkSize <= 0 {
+		chunkSize = total
+	}
+	ctx = s.insertContext(ctx)
+	conn, err := s.conn()
+	if err != nil {
 		return err
 	}
 
-	s.Conn = conn
+	for from := 0; from < total; from += chunkSize {
+		to := min(from+chunkSize, total)
+		start := time.Now()
+
+		batch, err := conn.PrepareBatch(queryContext(ctx), query)
+		if err != nil {
+			s.Log.WithErrorContext(ctx, err, "error on prepare batch Clickhouse", logger.Table, tableName)
+			return err
+		}
+
+		for i := from; i < to; i++ {
+			if err := batch.Append(row(i)...); err != nil {
+				s.Log.WithErrorContext(ctx, err, "error append batch in clickhouse", logger.Table, tableName)
+				return err
+			}
+		}
+
+		if err := batch.Send(); err != nil {
+			s.Log.WithErrorContext(ctx, err, "error send batch in clickhouse", logger.Table, tableName)
+			return err
+		}
+		s.Log.InfoContext(ctx, "saved chunk", logger.Table, tableName, logger.Rows, to, "total", total,
+			logger.Duration, time.Since(start))
+	}
 	return nil
 }
 
-// executeBatch выполняет пакетный запрос в ClickHouse.
+// deleteProfile удаляет из таблицы строки профиля, не затрагивая данные других профилей. Способ удаления
+// выбирается так, чтобы по возможности не запускать мутацию, которая переписывает все части таблицы:
+//   - производные таблицы разбиты на партиции по профилю, и профиль удаляется одним DROP PARTITION;
+//   - таблицы сущностей с датой разбиты на партиции по профилю и году, и удаляются все партиции профиля;
+//   - остальные таблицы сущностей на партиции не разбиты. Если в таблице нет строк других профилей, она
+//     очищается через TRUNCATE, иначе выполняется мутация ALTER TABLE ... DELETE. Мутация синхронная и
+//     переписывает все части таблицы, но это справочники, счета, категории, мерчанты и напоминания, которые
+//     занимают немного места.
+//
+// В режиме кластера удаление выполняется в локальных таблицах на всех узлах кластера,
+// так как Distributed-таблица не хранит данные.
 // Параметры:
 // - ctx: контекст для управления временем выполнения и отменой запроса.
-// - query: строка с SQL-запросом для выполнения пакетного вставки данных.
-// - data: срез с данными, которые будут вставлены в таблицу.
-func (s *Store) executeBatch(ctx context.Context, query string, data [][]interface{}) error {
-	batch, err := s.Conn.PrepareBatch(ctx, query)
+// - tableName: имя таблицы, из которой удаляются данные.
+// - profile: метка профиля, строки которого нужно удалить.
+func (s *Store) deleteProfile(ctx context.Context, tableName string, profile string) error {
+	conn, err := s.conn()
 	if err != nil {
-		s.Log.WithError(err, "error on prepare batch Clickhouse")
 		return err
 	}
 
-	for _, item := range data {
-		if err := batch.Append(item...); err != nil {
-			s.Log.WithError(err, "error append batch in clickhouse")
+	table := s.localTable(tableName)
+	switch {
+	case profilePartitioned[tableName]:
+		query := fmt.Sprintf("ALTER TABLE %s%s DROP PARTITION ?", table, s.onCluster())
+		return conn.Exec(queryContext(ctx), query, profile)
+	case entity.IsDated(tableName):
+		return s.dropProfileYears(ctx, tableName, profile)
+	}
+
+	var others uint64
+	query := fmt.Sprintf("SELECT count() FROM %s WHERE profile != ?", tableName)
+	if err := conn.QueryRow(queryContext(ctx), query, profile).Scan(&others); err != nil {
+		return err
+	}
+	if others == 0 {
+		query := fmt.Sprintf("TRUNCATE TABLE IF EXISTS %s%s", table, s.onCluster())
+		return conn.Exec(queryContext(ctx), query)
+	}
+	// Мутация ждет завершения, чтобы она не удалила строки, вставленные сразу после нее
+	query = fmt.Sprintf("ALTER TABLE %s%s DELETE WHERE profile = ?", table, s.onCluster())
+	return conn.Exec(queryContext(mutationContext(ctx)), query, profile)
+}
+
+// dropProfileYears удаляет все партиции профиля из таблицы сущности с датой. Годы профиля читаются
+// из исходной таблицы, в режиме кластера - со всех шардов.
+func (s *Store) dropProfileYears(ctx context.Context, tableName string, profile string) error {
+	conn, err := s.conn()
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("SELECT DISTINCT toYear(toDateOrZero(date)) FROM %s WHERE profile = ?", tableName)
+	rows, err := conn.Query(queryContext(ctx), query, profile)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var years []uint16
+	for rows.Next() {
+		var year uint16
+		if err := rows.Scan(&year); err != nil {
+			return err
+		}
+		years = append(years, year)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	drop := fmt.Sprintf("ALTER TABLE %s%s DROP PARTITION (?, ?)", s.localTable(tableName), s.onCluster())
+	for _, year := range years {
+		if err := conn.Exec(queryContext(ctx), drop, profile, year); err != nil {
 			return err
 		}
 	}
+	return nil
+}
+
+// deleteRows удаляет строки профиля перед вставкой. Для сущностей с датой при заданном периоде SINCE/UNTIL
+// удаляются только строки за период, иначе - все строки профиля.
+func (s *Store) deleteRows(ctx context.Context, tableName string, profile string) error {
+	period, err := s.period()
+	if err != nil {
+		return err
+	}
+	if !entity.IsDated(tableName) || period.IsZero() {
+		return s.deleteProfile(ctx, tableName, profile)
+	}
+	return s.deletePeriod(ctx, tableName, profile, period)
+}
 
-	if err := batch.Send(); err != nil {
-		s.Log.WithError(err, "error send batch in clickhouse")
+// deletePeriod удаляет строки профиля за период. Таблицы сущностей с датой разбиты на партиции
+// по профилю и году, поэтому годы, целиком попадающие в период, удаляются через DROP PARTITION,
+// а неполные годы - мутацией только внутри своей партиции. Данные других лет не затрагиваются.
+// Для периода, ограниченного с одной стороны, выполняется одна мутация по условию на дату.
+func (s *Store) deletePeriod(ctx context.Context, tableName string, profile string, period entity.Period) error {
+	conn, err := s.conn()
+	if err != nil {
 		return err
 	}
+
+	table := s.localTable(tableName)
+	ctx = mutationContext(ctx)
+
+	if !period.Bounded() {
+		query := fmt.Sprintf("ALTER TABLE %s%s DELETE WHERE profile = ?", table, s.onCluster())
+		args := []interface{}{profile}
+		if !period.Since.IsZero() {
+			query += " AND date >= ?"
+			args = append(args, period.Since.Format(entity.DateLayout))
+		}
+		if !period.Until.IsZero() {
+			query += " AND date <= ?"
+			args = append(args, period.Until.Format(entity.DateLayout))
+		}
+		return conn.Exec(queryContext(ctx), query, args...)
+	}
+
+	for _, year := range period.Years() {
+		if year.Full {
+			query := fmt.Sprintf("ALTER TABLE %s%s DROP PARTITION (?, ?)", table, s.onCluster())
+			if err := conn.Exec(queryContext(ctx), query, profile, year.Partition); err != nil {
+				return err
+			}
+			continue
+		}
+
+		query := fmt.Sprintf(
+			"ALTER TABLE %s%s DELETE IN PARTITION (?, ?) WHERE profile = ? AND date >= ? AND date <= ?",
+			table, s.onCluster(),
+		)
+		err := conn.Exec(queryContext(ctx), query, profile, year.Partition, profile,
+			year.Since.Format(entity.DateLayout), year.Until.Format(entity.DateLayout))
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-// truncateTable очищает указанную таблицу в базе данных ClickHouse.
-// Параметры:
-// - ctx: контекст для управления временем выполнения и отменой запроса.
-// - tableName: имя таблицы, которую необходимо очистить.
-func (s *Store) truncateTable(ctx context.Context, tableName string) error {
-	query := fmt.Sprintf("TRUNCATE TABLE IF EXISTS %s", tableName)
-	return s.Conn.Exec(ctx, query)
+// mutationContext возвращает контекст, в котором мутации ждут завершения на всех репликах,
+// чтобы последующая вставка не попала под удаление.
+func mutationContext(ctx context.Context) context.Context {
+	return clickhouse.Context(ctx, clickhouse.WithSettings(clickhouse.Settings{
+		"mutations_sync": 2,
+	}))
 }
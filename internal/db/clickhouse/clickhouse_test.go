@@ -0,0 +1,425 @@
+package clickhouse
+
+import (
+	"context"
+	"github.com/ClickHouse/clickhouse-go/v2/lib/driver"
+	"github.com/nemirlev/zenapi"
+	"github.com/nemirlev/zenexport/internal/audit"
+	"github.com/nemirlev/zenexport/internal/config"
+	"github.com/nemirlev/zenexport/internal/entity"
+	"github.com/nemirlev/zenexport/internal/logger"
+	"github.com/nemirlev/zenexport/migration"
+	"github.com/stretchr/testify/assert"
+	"io/fs"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// tableRow строка таблицы в fakeConn: профиль и дата в формате YYYY-MM-DD.
+type tableRow struct {
+	profile string
+	date    string
+}
+
+// year возвращает год партиции строки, как toYear(toDateOrZero(date)) в ClickHouse.
+func (r tableRow) year() uint16 {
+	year, _ := strconv.ParseUint(r.date[:4], 10, 16)
+	return uint16(year)
+}
+
+// fakeConn имитирует одну таблицу ClickHouse и выполняет только запросы удаления, которые строит deleteProfile.
+// Выполненные запросы записываются в statements.
+type fakeConn struct {
+	driver.Conn
+	rows       []tableRow
+	statements []string
+}
+
+func (c *fakeConn) keep(keep func(r tableRow) bool) {
+	var rows []tableRow
+	for _, r := range c.rows {
+		if keep(r) {
+			rows = append(rows, r)
+		}
+	}
+	c.rows = rows
+}
+
+func (c *fakeConn) Exec(_ context.Context, query string, args ...any) error {
+	c.statements = append(c.statements, query)
+	switch {
+	case strings.HasPrefix(query, "TRUNCATE"):
+		c.rows = nil
+	case strings.HasSuffix(query, "DROP PARTITION (?, ?)"):
+		c.keep(func(r tableRow) bool { return r.profile != args[0] || r.year() != args[1] })
+	case strings.HasSuffix(query, "DROP PARTITION ?"), strings.HasSuffix(query, "DELETE WHERE profile = ?"):
+		c.keep(func(r tableRow) bool { return r.profile != args[0] })
+	}
+	return nil
+}
+
+func (c *fakeConn) QueryRow(_ context.Context, query string, args ...any) driver.Row {
+	c.statements = append(c.statements, query)
+	var count uint64
+	for _, r := range c.rows {
+		if r.profile != args[0] {
+			count++
+		}
+	}
+	return fakeRow{value: count}
+}
+
+func (c *fakeConn) Query(_ context.Context, query string, args ...any) (driver.Rows, error) {
+	c.statements = append(c.statements, query)
+	seen := map[uint16]bool{}
+	var years []uint16
+	for _, r := range c.rows {
+		if r.profile == args[0] && !seen[r.year()] {
+			seen[r.year()] = true
+			years = append(years, r.year())
+		}
+	}
+	return &fakeRows{years: years, i: -1}, nil
+}
+
+type fakeRow struct {
+	driver.Row
+	value uint64
+}
+
+func (r fakeRow) Scan(dest ...any) error {
+	*dest[0].(*uint64) = r.value
+	return nil
+}
+
+type fakeRows struct {
+	driver.Rows
+	years []uint16
+	i     int
+}
+
+func (r *fakeRows) Next() bool {
+	r.i++
+	return r.i < len(r.years)
+}
+
+func (r *fakeRows) Scan(dest ...any) error {
+	*dest[0].(*uint16) = r.years[r.i]
+	return nil
+}
+
+func (r *fakeRows) Err() error   { return nil }
+func (r *fakeRows) Close() error { return nil }
+
+// Тестируем, что удаление профиля не затрагивает строки других профилей и не запускает лишних мутаций
+func TestDeleteProfile(t *testing.T) {
+	ctx := context.Background()
+	rows := []tableRow{{"main", "2023-12-31"}, {"main", "2024-01-15"}, {"family", "2024-01-15"}}
+
+	tests := []struct {
+		table     string
+		statement string
+	}{
+		// Производная таблица разбита на партиции по профилю
+		{balanceDailyTable, "ALTER TABLE account_balance_daily DROP PARTITION ?"},
+		// Таблица сущности с датой разбита на партиции по профилю и году
+		{entity.Transaction, "ALTER TABLE transaction DROP PARTITION (?, ?)"},
+		// Таблица без партиций с данными другого профиля
+		{entity.Account, "ALTER TABLE account DELETE WHERE profile = ?"},
+	}
+	for _, tt := range tests {
+		conn := &fakeConn{rows: append([]tableRow{}, rows...)}
+		s := &Store{Conn: conn, Config: &config.Config{}}
+
+		assert.NoError(t, s.deleteProfile(ctx, tt.table, "main"), tt.table)
+		assert.Equal(t, []tableRow{{"family", "2024-01-15"}}, conn.rows, tt.table)
+		assert.Contains(t, conn.statements, tt.statement, tt.table)
+		for _, statement := range conn.statements {
+			assert.NotContains(t, statement, "TRUNCATE", tt.table)
+		}
+	}
+
+	// Таблица без партиций только с одним профилем очищается без мутации
+	conn := &fakeConn{rows: []tableRow{{"main", "2024-01-15"}}}
+	s := &Store{Conn: conn, Config: &config.Config{}}
+	assert.NoError(t, s.deleteProfile(ctx, entity.Account, "main"))
+	assert.Empty(t, conn.rows)
+	assert.Equal(t, "TRUNCATE TABLE IF EXISTS account", conn.statements[len(conn.statements)-1])
+}
+
+// Тестируем, что история больше чем за 100 месяцев удаляется числом партиций в пределах лимита
+// max_partitions_per_insert_block, с которым ClickHouse отклоняет вставку
+func TestDeleteProfileLongHistory(t *testing.T) {
+	var rows []tableRow
+	start := time.Date(2015, time.January, 10, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 120; i++ {
+		rows = append(rows, tableRow{"main", start.AddDate(0, i, 0).Format(entity.DateLayout)})
+	}
+	conn := &fakeConn{rows: append(rows, tableRow{"family", "2020-05-01"})}
+	s := &Store{Conn: conn, Config: &config.Config{}}
+
+	assert.NoError(t, s.deleteProfile(context.Background(), entity.Transaction, "main"))
+	assert.Equal(t, []tableRow{{"family", "2020-05-01"}}, conn.rows)
+
+	var drops int
+	for _, statement := range conn.statements {
+		if strings.Contains(statement, "DROP PARTITION") {
+			drops++
+		}
+	}
+	assert.Equal(t, 10, drops)
+}
+
+// Тестируем экранирование имени кластера в DDL и миграциях
+func TestOnCluster(t *testing.T) {
+	s := &Store{Config: &config.Config{}}
+	assert.Equal(t, "", s.onCluster())
+	assert.Equal(t, "CREATE TABLE t ON CLUSTER '{cluster}'", s.clusterQuery("CREATE TABLE t ON CLUSTER '{cluster}'"))
+
+	s.Config.ClickhouseCluster = "my-cluster"
+	assert.Equal(t, " ON CLUSTER `my-cluster`", s.onCluster())
+	assert.Equal(t,
+		"CREATE TABLE t ON CLUSTER 'my-cluster' ENGINE = Distributed('my-cluster', currentDatabase(), t_local, rand())",
+		s.clusterQuery("CREATE TABLE t ON CLUSTER '{cluster}' ENGINE = Distributed('{cluster}', currentDatabase(), t_local, rand())"),
+	)
+
+	assert.Equal(t, "`a\\`b`", quoteIdentifier("a`b"))
+	assert.Equal(t, `'a\'b'`, quoteString("a'b"))
+}
+
+// Тестируем проверку имени кластера
+func TestValidateConfig(t *testing.T) {
+	for _, name := range []string{"", "main", "my-cluster", "{cluster}", "prod_1.eu"} {
+		assert.Empty(t, validateConfig(config.Config{ClickhouseCluster: name}), name)
+	}
+	for _, name := range []string{"my cluster", "c;DROP", "c`", "c'"} {
+		assert.Len(t, validateConfig(config.Config{ClickhouseCluster: name}), 1, name)
+	}
+}
+
+// migrationConn имитирует базу с таблицей zenexport_migrations и, если legacy не nil, с таблицей schema_migrations
+// утилиты migrate. Выполненные миграции записываются в statements, а отмеченные версии - в recorded.
+type migrationConn struct {
+	driver.Conn
+	applied    []uint64
+	legacy     []any
+	statements []string
+	recorded   []uint64
+}
+
+func (c *migrationConn) Ping(context.Context) error { return nil }
+
+func (c *migrationConn) Exec(_ context.Context, query string, args ...any) error {
+	switch {
+	case strings.HasPrefix(query, "INSERT INTO "+migrationsTable):
+		c.recorded = append(c.recorded, args[0].(uint64))
+	case !strings.Contains(query, "CREATE TABLE IF NOT EXISTS "+migrationsTable):
+		c.statements = append(c.statements, query)
+	}
+	return nil
+}
+
+func (c *migrationConn) Query(context.Context, string, ...any) (driver.Rows, error) {
+	return &versionRows{versions: c.applied, i: -1}, nil
+}
+
+func (c *migrationConn) QueryRow(_ context.Context, query string, _ ...any) driver.Row {
+	if strings.HasPrefix(query, "EXISTS TABLE") {
+		return valuesRow{values: []any{uint8(len(c.legacy) / 2)}}
+	}
+	return valuesRow{values: c.legacy}
+}
+
+type versionRows struct {
+	driver.Rows
+	versions []uint64
+	i        int
+}
+
+func (r *versionRows) Next() bool {
+	r.i++
+	return r.i < len(r.versions)
+}
+
+func (r *versionRows) Scan(dest ...any) error {
+	*dest[0].(*uint64) = r.versions[r.i]
+	return nil
+}
+
+func (r *versionRows) Err() error   { return nil }
+func (r *versionRows) Close() error { return nil }
+
+// valuesRow строка с произвольными значениями столбцов.
+type valuesRow struct {
+	driver.Row
+	values []any
+}
+
+func (r valuesRow) Scan(dest ...any) error {
+	for i := range dest {
+		reflect.ValueOf(dest[i]).Elem().Set(reflect.ValueOf(r.values[i]))
+	}
+	return nil
+}
+
+// Тестируем, что миграции, уже примененные утилитой migrate, не применяются повторно
+func TestMigrateLegacyVersion(t *testing.T) {
+	ctx := context.Background()
+	log, err := logger.NewWithOptions(logger.Options{Level: "error"})
+	assert.NoError(t, err)
+	const legacy = 20261019120023
+
+	conn := &migrationConn{legacy: []any{int64(legacy), uint8(0)}}
+	s := &Store{Conn: conn, Log: log, Config: &config.Config{}}
+	assert.NoError(t, s.Migrate(ctx))
+
+	// Миграции до версии утилиты migrate только отмечаются примененными, в том числе миграции переразбиения
+	assert.NotEmpty(t, conn.statements)
+	for _, statement := range conn.statements {
+		assert.NotContains(t, statement, "RENAME TABLE")
+		assert.NotContains(t, statement, "_unpartitioned")
+	}
+	assert.Contains(t, conn.recorded, uint64(legacy))
+	assert.Contains(t, conn.recorded, uint64(20261019120020))
+
+	// Повторный запуск не применяет и не отмечает ничего
+	conn = &migrationConn{applied: conn.recorded, legacy: conn.legacy}
+	s.Conn = conn
+	assert.NoError(t, s.Migrate(ctx))
+	assert.Empty(t, conn.statements)
+	assert.Empty(t, conn.recorded)
+
+	// Без schema_migrations применяются все миграции
+	conn = &migrationConn{}
+	s.Conn = conn
+	assert.NoError(t, s.Migrate(ctx))
+	assert.Len(t, conn.statements, len(conn.recorded))
+	assert.Contains(t, strings.Join(conn.statements, "\n"), "RENAME TABLE transaction TO transaction_unpartitioned")
+
+	// Прерванная миграция утилиты migrate - ошибка
+	conn = &migrationConn{legacy: []any{int64(legacy), uint8(1)}}
+	s.Conn = conn
+	assert.Error(t, s.Migrate(ctx))
+	assert.Empty(t, conn.statements)
+}
+
+// auditConn имитирует таблицу transaction для чтения транзакций аудита.
+type auditConn struct {
+	driver.Conn
+	stored []zenapi.Transaction
+}
+
+func (c *auditConn) Query(_ context.Context, query string, args ...any) (driver.Rows, error) {
+	var rows [][]any
+	if strings.Contains(query, "toString(changed)") {
+		// Версии строк за период: аргументы после профиля - границы дат
+		period := entity.Period{}
+		if len(args) == 3 {
+			period.Since, _ = time.Parse(entity.DateLayout, args[1].(string))
+			period.Until, _ = time.Parse(entity.DateLayout, args[2].(string))
+		}
+		for _, t := range c.stored {
+			if period.Contains(t.Date) {
+				rows = append(rows, []any{t.ID, strconv.Itoa(t.Changed)})
+			}
+		}
+		return &valuesRows{rows: rows, i: -1}, nil
+	}
+
+	ids := args[1].([]string)
+	for _, t := range c.stored {
+		for _, id := range ids {
+			if t.ID == id {
+				rows = append(rows, []any{t.ID, int32(t.Changed), int32(t.User), t.Deleted, t.Hold,
+					int32(t.IncomeInstrument), t.IncomeAccount, t.Income, int32(t.OutcomeInstrument), t.OutcomeAccount,
+					t.Outcome, t.Tag, t.Merchant, t.Payee, t.Comment, t.Date})
+			}
+		}
+	}
+	return &valuesRows{rows: rows, i: -1}, nil
+}
+
+type valuesRows struct {
+	driver.Rows
+	rows [][]any
+	i    int
+}
+
+func (r *valuesRows) Next() bool {
+	r.i++
+	return r.i < len(r.rows)
+}
+
+func (r *valuesRows) Scan(dest ...any) error {
+	return valuesRow{values: r.rows[r.i]}.Scan(dest...)
+}
+
+func (r *valuesRows) Err() error   { return nil }
+func (r *valuesRows) Close() error { return nil }
+
+// Тестируем, что перенос даты транзакции за границу периода записывается как изменение, а не удаление
+func TestAuditedTransactionsPeriod(t *testing.T) {
+	conn := &auditConn{stored: []zenapi.Transaction{
+		{ID: "moved-out", Changed: 1, Date: "2024-05-01"},
+		{ID: "deleted", Changed: 1, Date: "2024-06-01"},
+		{ID: "moved-in", Changed: 1, Date: "2023-01-01"},
+		{ID: "outside", Changed: 1, Date: "2023-02-01"},
+	}}
+	incoming := []zenapi.Transaction{
+		{ID: "moved-out", Changed: 2, Date: "2023-12-31"},
+		{ID: "moved-in", Changed: 2, Date: "2024-02-01"},
+		{ID: "outside", Changed: 2, Date: "2023-03-01"},
+		{ID: "new", Changed: 2, Date: "2024-03-01"},
+	}
+	s := &Store{Conn: conn, Config: &config.Config{}}
+	period := entity.Period{Since: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Until: time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)}
+
+	stored, err := s.auditedTransactions(context.Background(), "main", incoming, period)
+	assert.NoError(t, err)
+
+	observedAt := time.Now()
+	types := map[string]string{}
+	for _, c := range audit.Compare(stored, incoming, observedAt) {
+		types[c.TransactionID+"/"+c.Field] = c.Type
+	}
+	assert.Equal(t, audit.Update, types["moved-out/date"])
+	assert.Equal(t, audit.Update, types["moved-in/date"])
+	assert.Equal(t, audit.Delete, types["deleted/date"])
+	assert.NotContains(t, types, "outside/date")
+	assert.NotContains(t, types, "new/date")
+}
+
+// Тестируем, что запросы к закрытому хранилищу возвращают ошибку, а не обращаются к пустому пулу
+func TestClosedStore(t *testing.T) {
+	s := &Store{Config: &config.Config{}}
+	_, err := s.conn()
+	assert.ErrorIs(t, err, errNotConnected)
+	assert.ErrorIs(t, s.deleteProfile(context.Background(), entity.Account, "main"), errNotConnected)
+	assert.ErrorIs(t, s.Ping(context.Background()), errNotConnected)
+}
+
+// Тестируем, что сущности заданы только для существующих миграций
+func TestMigrationEntities(t *testing.T) {
+	versions := map[uint64]bool{}
+	for _, dir := range []string{"clickhouse", "clickhouse_cluster"} {
+		files, err := fs.Glob(migration.FS, path.Join(dir, "*.up.sql"))
+		assert.NoError(t, err)
+		for _, file := range files {
+			version, err := strconv.ParseUint(strings.SplitN(path.Base(file), "_", 2)[0], 10, 64)
+			assert.NoError(t, err)
+			versions[version] = true
+		}
+	}
+	for version := range migrationEntities {
+		assert.True(t, versions[version], version)
+	}
+
+	// Прогноз строится по напоминаниям, а таблица запусков нужна всегда
+	assert.Equal(t, entity.Reminder, migrationEntities[20261019210000])
+	assert.Equal(t, entity.Transaction, migrationEntities[20261019200010])
+	assert.NotContains(t, migrationEntities, uint64(20261019110000))
+}
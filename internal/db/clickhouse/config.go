@@ -0,0 +1,33 @@
+package clickhouse
+
+import (
+	"github.com/nemirlev/zenexport/internal/config"
+	"regexp"
+)
+
+// clusterName допустимое имя кластера или макрос вида {cluster}. Имя подставляется в DDL в кавычках,
+// но ограничение набора символов защищает от имен, которые ClickHouse разберет иначе, чем задумано.
+var clusterName = regexp.MustCompile(`^[A-Za-z0-9_.{}-]+$`)
+
+// ConfigBackend схема настроек ClickHouse. Пароль не обязателен, чтобы можно было подключаться
+// к локальному серверу без пароля.
+var ConfigBackend = config.Backend{
+	Required: []string{
+		"database.clickhouse.server",
+		"database.clickhouse.user",
+		"database.clickhouse.db",
+	},
+	Validate: validateConfig,
+}
+
+// validateConfig проверяет настройки ClickHouse, которые нельзя описать списком обязательных полей.
+func validateConfig(c config.Config) []config.Problem {
+	var problems []config.Problem
+	if c.ClickhouseCluster != "" && !clusterName.MatchString(c.ClickhouseCluster) {
+		problems = append(problems, config.Problem{
+			Field:   "database.clickhouse.cluster",
+			Message: "must be a cluster name or macro of letters, digits and _ . - { }",
+		})
+	}
+	return problems
+}
@@ -1,11 +1,12 @@
 package clickhouse
 
 import (
+	"context"
 	"github.com/nemirlev/zenapi"
 )
 
 // Delete удаляет данные из ClickHouse.
-func (s *Store) Delete(data *zenapi.Deletion) error {
+func (s *Store) Delete(ctx context.Context, data *zenapi.Deletion) error {
 	// TODO: Реализовать после того, как будет реализовано обновление
 	panic("implement me")
 }
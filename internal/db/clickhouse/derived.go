@@ -0,0 +1,185 @@
+package clickhouse
+
+import (
+	"context"
+	"github.com/nemirlev/zenapi"
+	"github.com/nemirlev/zenexport/internal/budget"
+	"github.com/nemirlev/zenexport/internal/category"
+	"github.com/nemirlev/zenexport/internal/currency"
+	"github.com/nemirlev/zenexport/internal/db/model"
+	"github.com/nemirlev/zenexport/internal/forecast"
+	"github.com/nemirlev/zenexport/internal/ledger"
+	"github.com/nemirlev/zenexport/internal/logger"
+	"time"
+)
+
+// Производные таблицы, которые вычисляются из данных ZenMoney и перезаписываются целиком для профиля.
+const (
+	balanceDailyTable   = "account_balance_daily"
+	reconciliationTable = "reconciliation"
+	ledgerEntryTable    = "ledger_entry"
+	tagPathTable        = "tag_path"
+	transactionTagTable = "transaction_tag"
+	forecastTable       = "forecast_transaction"
+	budgetActualTable   = "budget_actual"
+)
+
+// profilePartitioned содержит таблицы, разбитые на партиции только по профилю. Данные профиля в них
+// удаляются через DROP PARTITION без мутаций.
+var profilePartitioned = map[string]bool{
+	balanceDailyTable:   true,
+	reconciliationTable: true,
+	ledgerEntryTable:    true,
+	tagPathTable:        true,
+	transactionTagTable: true,
+	forecastTable:       true,
+	budgetActualTable:   true,
+}
+
+// saveDailyBalances сохраняет ежедневные остатки счетов в таблицу account_balance_daily.
+// Остатки считаются от start_balance по всем транзакциям, поэтому data не должна быть ограничена периодом.
+func (s *Store) saveDailyBalances(ctx context.Context, profile string, data *zenapi.Response, until time.Time) error {
+	query := `
+		INSERT INTO account_balance_daily (
+			account, user, instrument, date, income, outcome, balance, profile
+		) VALUES (
+			?, ?, ?, ?, ?, ?, ?, ?
+		)
+	`
+
+	balances := ledger.Daily(data.Account, data.Transaction, until)
+	return s.saveBatch(ctx, profile, balanceDailyTable, query, len(balances), func(i int) []interface{} {
+		b := balances[i]
+		return []interface{}{b.Account, b.User, b.Instrument, b.Date, b.Income, b.Outcome, b.Balance}
+	})
+}
+
+// saveLedgerEntries сохраняет движения по счетам всех транзакций с их видом в таблицу ledger_entry.
+func (s *Store) saveLedgerEntries(ctx context.Context, profile string, data *zenapi.Response) error {
+	query := `
+		INSERT INTO ledger_entry (
+			transaction_id, account, instrument, user, date, kind, amount, tag, merchant, payee, profile
+		) VALUES (
+			?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+		)
+	`
+
+	entries := ledger.Entries(data.Account, data.Transaction)
+	return s.saveBatch(ctx, profile, ledgerEntryTable, query, len(entries), func(i int) []interface{} {
+		e := entries[i]
+		return []interface{}{
+			e.Transaction, e.Account, e.Instrument, e.User, e.Date, string(e.Kind), e.Amount, e.Tag, e.Merchant, e.Payee,
+		}
+	})
+}
+
+// saveTagPaths сохраняет положение каждой категории в иерархии в таблицу tag_path.
+func (s *Store) saveTagPaths(ctx context.Context, profile string, tags []zenapi.Tag) error {
+	query := `
+		INSERT INTO tag_path (
+			tag, user, title, root, root_title, subcategory, subcategory_title, path, depth, profile
+		) VALUES (
+			?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+		)
+	`
+
+	paths := category.NewTree(tags).Paths()
+	return s.saveBatch(ctx, profile, tagPathTable, query, len(paths), func(i int) []interface{} {
+		p := paths[i]
+		return []interface{}{
+			p.Tag, p.User, p.Title, p.Root, p.RootTitle, p.Subcategory, p.SubcategoryTitle, p.Path, uint8(p.Depth),
+		}
+	})
+}
+
+// saveTransactionTags сохраняет связи транзакций с категориями в таблицу transaction_tag.
+func (s *Store) saveTransactionTags(ctx context.Context, profile string, transactions []zenapi.Transaction) error {
+	query := `
+		INSERT INTO transaction_tag (
+			transaction_id, tag, position, is_primary, profile
+		) VALUES (
+			?, ?, ?, ?, ?
+		)
+	`
+
+	links := category.TransactionTags(transactions)
+	return s.saveBatch(ctx, profile, transactionTagTable, query, len(links), func(i int) []interface{} {
+		l := links[i]
+		return []interface{}{l.Transaction, l.Tag, uint8(l.Position), l.Primary}
+	})
+}
+
+// saveForecast сохраняет плановые операции напоминаний с from на Config.ForecastDays дней вперед в таблицу
+// forecast_transaction.
+func (s *Store) saveForecast(ctx context.Context, profile string, data *zenapi.Response, from time.Time) error {
+	query := `
+		INSERT INTO forecast_transaction (
+			reminder, user, date, income_instrument, income_account, income, outcome_instrument, outcome_account,
+			outcome, tag, merchant, payee, comment, reminder_marker, marker_state, profile
+		) VALUES (
+			?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+		)
+	`
+
+	reminders := make(map[string]zenapi.Reminder, len(data.Reminder))
+	for _, r := range data.Reminder {
+		reminders[r.ID] = r
+	}
+	occurrences := forecast.Expand(data.Reminder, data.ReminderMarker, from, from.AddDate(0, 0, s.Config.ForecastDays))
+	return s.saveBatch(ctx, profile, forecastTable, query, len(occurrences), func(i int) []interface{} {
+		o := occurrences[i]
+		r := reminders[o.Reminder]
+		return []interface{}{
+			r.ID, r.User, o.Date, r.IncomeInstrument, r.IncomeAccount, r.Income, r.OutcomeInstrument, r.OutcomeAccount,
+			r.Outcome, r.Tag, r.Merchant, r.Payee, r.Comment, o.Marker, o.State,
+		}
+	})
+}
+
+// saveBudgetActual сохраняет план и факт бюджетов по категориям и месяцам в таблицу budget_actual.
+func (s *Store) saveBudgetActual(ctx context.Context, profile string, data *zenapi.Response, converter *currency.Converter) error {
+	query := `
+		INSERT INTO budget_actual (
+			user, tag, month, planned_income, actual_income, income_variance,
+			planned_outcome, actual_outcome, outcome_variance, profile
+		) VALUES (
+			?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+		)
+	`
+
+	actuals, unconverted := budget.Compare(data, converter)
+	if unconverted > 0 {
+		s.Log.DebugContext(ctx, "transactions not counted in budgets", logger.Table, budgetActualTable, logger.Rows, unconverted)
+	}
+	return s.saveBatch(ctx, profile, budgetActualTable, query, len(actuals), func(i int) []interface{} {
+		a := actuals[i]
+		return []interface{}{
+			a.User, a.Tag, a.Month, a.PlannedIncome, a.ActualIncome, a.IncomeVariance,
+			a.PlannedOutcome, a.ActualOutcome, a.OutcomeVariance,
+		}
+	})
+}
+
+// SaveReconciliation сохраняет результаты сверки остатков счетов в таблицу reconciliation, заменяя результаты
+// предыдущей сверки профиля.
+func (s *Store) SaveReconciliation(ctx context.Context, profile string, results []model.Reconciliation) error {
+	if err := s.ensureConnection(ctx); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO reconciliation (
+			account, title, instrument, balance, computed, difference, tolerance, status, checked_at, profile
+		) VALUES (
+			?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+		)
+	`
+
+	checkedAt := time.Now()
+	return s.saveBatch(ctx, profile, reconciliationTable, query, len(results), func(i int) []interface{} {
+		r := results[i]
+		return []interface{}{
+			r.Account, r.Title, r.Instrument, r.Balance, r.Computed, r.Difference, r.Tolerance, r.Status, checkedAt,
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package clickhouse
+
+import (
+	"context"
+	"github.com/nemirlev/zenapi"
+	"github.com/nemirlev/zenexport/internal/currency"
+	"github.com/nemirlev/zenexport/internal/logger"
+	"time"
+)
+
+// rateHistoryTable таблица истории курсов инструментов.
+const rateHistoryTable = "instrument_rate_history"
+
+// appendRateHistory добавляет текущие курсы инструментов в таблицу instrument_rate_history с отметкой
+// времени observedAt. В отличие от таблиц сущностей, история не перезаписывается, а только дополняется.
+func (s *Store) appendRateHistory(ctx context.Context, profile string, instruments []zenapi.Instrument, observedAt time.Time) error {
+	query := `
+		INSERT INTO instrument_rate_history (
+			instrument, rate, observed_at, profile
+		) VALUES (
+			?, ?, ?, ?
+		)
+	`
+
+	start := time.Now()
+	err := s.executeBatch(ctx, rateHistoryTable, query, len(instruments), func(i int) []interface{} {
+		instrument := instruments[i]
+		return []interface{}{instrument.ID, instrument.Rate, observedAt, profile}
+	})
+	if err != nil {
+		s.Log.WithErrorContext(ctx, err, "failed to save rate history", logger.Table, rateHistoryTable)
+		return err
+	}
+	s.Log.InfoContext(ctx, "saved table", logger.Table, rateHistoryTable, logger.Rows, len(instruments),
+		logger.Duration, time.Since(start))
+	return nil
+}
+
+// converter создает конвертер валют по данным ZenMoney. Если withHistory, суммы на дату пересчитываются по истории
+// курсов профиля, иначе по текущим курсам: без инструментов таблицы истории может не быть.
+func (s *Store) converter(ctx context.Context, profile string, data *zenapi.Response, withHistory bool) (*currency.Converter, error) {
+	converter := currency.New(data)
+	if !withHistory {
+		return converter, nil
+	}
+	history, err := s.rateHistory(ctx, profile)
+	if err != nil {
+		return nil, err
+	}
+	return converter.WithHistory(history), nil
+}
+
+// rateHistory возвращает историю курсов инструментов профиля. Для каждого дня берется последний
+// наблюдавшийся курс, так как даты транзакций не содержат времени.
+func (s *Store) rateHistory(ctx context.Context, profile string) (*currency.History, error) {
+	conn, err := s.conn()
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT instrument, argMax(rate, observed_at), toDateTime(toDate(observed_at)) AS day
+		FROM instrument_rate_history
+		WHERE profile = ?
+		GROUP BY instrument, day
+	`
+	rows, err := conn.Query(queryContext(ctx), query, profile)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var points []currency.RatePoint
+	for rows.Next() {
+		var (
+			instrument int32
+			point      currency.RatePoint
+		)
+		if err := rows.Scan(&instrument, &point.Rate, &point.ObservedAt); err != nil {
+			return nil, err
+		}
+		point.Instrument = int(instrument)
+		points = append(points, point)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return currency.NewHistory(points), nil
+}
@@ -0,0 +1,316 @@
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+	"github.com/nemirlev/zenexport/internal/entity"
+	"github.com/nemirlev/zenexport/internal/logger"
+	"github.com/nemirlev/zenexport/migration"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// migrationsTable хранит версии примененных миграций. В отличие от schema_migrations утилиты migrate,
+// каждая миграция учитывается отдельно, поэтому пропущенные фильтром сущности можно применить позже.
+const migrationsTable = "zenexport_migrations"
+
+// Migrate применяет встроенные миграции, которые еще не были применены.
+// Миграции таблиц сущностей, отключенных фильтром INCLUDE_ENTITIES/EXCLUDE_ENTITIES, пропускаются.
+// Не все миграции идемпотентны: миграции переразбиения таблиц переименовывают и копируют таблицы. Поэтому при первом
+// запуске на базе, созданной утилитой migrate, миграции до версии из schema_migrations отмечаются примененными.
+func (s *Store) Migrate(ctx context.Context) error {
+	if err := s.ensureConnection(ctx); err != nil {
+		return err
+	}
+	conn, err := s.conn()
+	if err != nil {
+		return err
+	}
+
+	filter, err := s.filter()
+	if err != nil {
+		return err
+	}
+
+	if err := s.createMigrationsTable(ctx); err != nil {
+		s.Log.WithErrorContext(ctx, err, "failed to create migrations table")
+		return err
+	}
+
+	applied, err := s.appliedMigrations(ctx)
+	if err != nil {
+		s.Log.WithErrorContext(ctx, err, "failed to read applied migrations")
+		return err
+	}
+
+	// Учет миграций утилиты migrate переносится, только пока встроенные миграции не применялись
+	var legacy uint64
+	if len(applied) == 0 {
+		if legacy, err = s.legacyMigrationVersion(ctx); err != nil {
+			s.Log.WithErrorContext(ctx, err, "failed to read schema_migrations")
+			return err
+		}
+	}
+
+	dir := "clickhouse"
+	if s.isCluster() {
+		dir = "clickhouse_cluster"
+	}
+	files, err := fs.Glob(migration.FS, path.Join(dir, "*.up.sql"))
+	if err != nil {
+		return err
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		name := path.Base(file)
+		version, err := strconv.ParseUint(strings.SplitN(name, "_", 2)[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid migration name %s: %w", name, err)
+		}
+		if applied[version] {
+			continue
+		}
+		if version <= legacy {
+			if err := s.recordMigration(ctx, version, name); err != nil {
+				s.Log.WithErrorContext(ctx, err, "failed to record migration", "migration", name)
+				return err
+			}
+			continue
+		}
+
+		if e := migrationEntities[version]; e != "" && !filter.Enabled(e) {
+			s.Log.InfoContext(ctx, "skip migration: entity is disabled", "migration", name, logger.Table, e)
+			continue
+		}
+
+		query, err := fs.ReadFile(migration.FS, file)
+		if err != nil {
+			return err
+		}
+
+		s.Log.InfoContext(ctx, "applying migration", "migration", name)
+		if err := conn.Exec(ctx, s.clusterQuery(string(query))); err != nil {
+			s.Log.WithErrorContext(ctx, err, "failed to apply migration", "migration", name)
+			return err
+		}
+
+		if err := s.recordMigration(ctx, version, name); err != nil {
+			s.Log.WithErrorContext(ctx, err, "failed to record migration", "migration", name)
+			return err
+		}
+	}
+
+	s.Log.InfoContext(ctx, "migrations completed")
+	return nil
+}
+
+// clusterMacro имя кластера в файлах миграций. Файлы используют макрос, чтобы их можно было применять утилитой
+// migrate, а при встроенном применении вместо него подставляется CLICKHOUSE_CLUSTER, чтобы миграции и запросы
+// очистки выполнялись на одном и том же кластере.
+const clusterMacro = "'{cluster}'"
+
+// clusterQuery подставляет в запрос миграции имя кластера из конфигурации.
+func (s *Store) clusterQuery(query string) string {
+	if !s.isCluster() {
+		return query
+	}
+	return strings.ReplaceAll(query, clusterMacro, quoteString(s.Config.ClickhouseCluster))
+}
+
+// createMigrationsTable создает таблицу учета миграций. В режиме кластера таблица реплицируется,
+// чтобы все узлы видели один и тот же набор примененных миграций.
+func (s *Store) createMigrationsTable(ctx context.Context) error {
+	conn, err := s.conn()
+	if err != nil {
+		return err
+	}
+
+	engine := "MergeTree"
+	if s.isCluster() {
+		engine = fmt.Sprintf("ReplicatedMergeTree('/clickhouse/tables/{shard}/{database}/%s', '{replica}')", migrationsTable)
+	}
+
+	query := fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s%s
+		(
+			version    UInt64,
+			name       String,
+			applied_at DateTime DEFAULT now()
+		) ENGINE = %s ORDER BY version
+	`, migrationsTable, s.onCluster(), engine)
+	return conn.Exec(ctx, query)
+}
+
+// appliedMigrations возвращает множество версий уже примененных миграций.
+func (s *Store) appliedMigrations(ctx context.Context) (map[uint64]bool, error) {
+	conn, err := s.conn()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := conn.Query(ctx, fmt.Sprintf("SELECT version FROM %s", migrationsTable))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[uint64]bool)
+	for rows.Next() {
+		var version uint64
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		applied[version] = true
+	}
+	return applied, rows.Err()
+}
+
+// recordMigration отмечает миграцию примененной.
+func (s *Store) recordMigration(ctx context.Context, version uint64, name string) error {
+	conn, err := s.conn()
+	if err != nil {
+		return err
+	}
+
+	insert := fmt.Sprintf("INSERT INTO %s (version, name) VALUES (?, ?)", migrationsTable)
+	return conn.Exec(ctx, insert, version, name)
+}
+
+// legacyMigrationsTable таблица версий утилиты migrate. В ней хранится история версий, а текущей версией
+// считается последняя запись.
+const legacyMigrationsTable = "schema_migrations"
+
+// legacyMigrationVersion возвращает версию схемы, до которой миграции применила утилита migrate, или 0,
+// если утилита не использовалась. Миграция, прерванная с ошибкой (dirty), - ошибка: ее нужно исправить
+// командой migrate force.
+func (s *Store) legacyMigrationVersion(ctx context.Context) (uint64, error) {
+	conn, err := s.conn()
+	if err != nil {
+		return 0, err
+	}
+
+	var exists uint8
+	if err := conn.QueryRow(ctx, "EXISTS TABLE "+legacyMigrationsTable).Scan(&exists); err != nil {
+		return 0, err
+	}
+	if exists == 0 {
+		return 0, nil
+	}
+
+	var (
+		version int64
+		dirty   uint8
+	)
+	query := fmt.Sprintf("SELECT argMax(version, sequence), argMax(dirty, sequence) FROM %s", legacyMigrationsTable)
+	if err := conn.QueryRow(ctx, query).Scan(&version, &dirty); err != nil {
+		return 0, err
+	}
+	if dirty != 0 {
+		return 0, fmt.Errorf("%s is dirty at version %d, fix it with migrate force", legacyMigrationsTable, version)
+	}
+	// После отката всех миграций утилита записывает версию -1
+	if version < 0 {
+		return 0, nil
+	}
+	return uint64(version), nil
+}
+
+// migrationEntities сущности, к таблицам которых относятся миграции, по версиям миграций. Миграции таблиц
+// сущностей, отключенных фильтром, пропускаются. Производная таблица относится к сущности, без которой она
+// не строится. Миграции, которых нет в списке (sync_run, reconciliation, ledger_entry), применяются всегда.
+// Новую миграцию таблицы сущности нужно добавить сюда вместе с миграцией Distributed-таблицы кластера.
+var migrationEntities = map[uint64]string{
+	20231126203134: entity.Instrument, // create_instrument_table
+	20231126203135: entity.Instrument, // create_instrument_distributed_table
+	20261019100000: entity.Instrument, // add_profile_to_instrument_table
+	20261019100001: entity.Instrument, // add_profile_to_instrument_distributed_table
+	20261019140000: entity.Instrument, // create_instrument_rate_history_table
+	20261019140001: entity.Instrument, // create_instrument_rate_history_distributed_table
+
+	20231126205612: entity.Country, // create_country_table
+	20231126205613: entity.Country, // create_country_distributed_table
+	20261019100010: entity.Country, // add_profile_to_country_table
+	20261019100011: entity.Country, // add_profile_to_country_distributed_table
+
+	20231126205340: entity.Company, // create_company_table
+	20231126205341: entity.Company, // create_company_distributed_table
+	20261019100020: entity.Company, // add_profile_to_company_table
+	20261019100021: entity.Company, // add_profile_to_company_distributed_table
+
+	20231126205528: entity.User, // create_user_table
+	20231126205529: entity.User, // create_user_distributed_table
+	20261019100030: entity.User, // add_profile_to_user_table
+	20261019100031: entity.User, // add_profile_to_user_distributed_table
+
+	20231126210926: entity.Account, // create_account_table
+	20231126210927: entity.Account, // create_account_distributed_table
+	20261019100040: entity.Account, // add_profile_to_account_table
+	20261019100041: entity.Account, // add_profile_to_account_distributed_table
+	20261019150000: entity.Account, // create_account_history_table
+	20261019150001: entity.Account, // create_account_history_distributed_table
+	20261019170000: entity.Account, // create_account_balance_daily_table
+	20261019170001: entity.Account, // create_account_balance_daily_distributed_table
+
+	20231126211012: entity.Tag, // create_tag_table
+	20231126211013: entity.Tag, // create_tag_distributed_table
+	20231216155428: entity.Tag, // change_tag_color_type
+	20261019100050: entity.Tag, // add_profile_to_tag_table
+	20261019100051: entity.Tag, // add_profile_to_tag_distributed_table
+	20261019150010: entity.Tag, // create_tag_history_table
+	20261019150011: entity.Tag, // create_tag_history_distributed_table
+	20261019200000: entity.Tag, // create_tag_path_table
+	20261019200001: entity.Tag, // create_tag_path_distributed_table
+
+	20231126211048: entity.Merchant, // create_merchant_table
+	20231126211049: entity.Merchant, // create_merchant_distributed_table
+	20261019100060: entity.Merchant, // add_profile_to_merchant_table
+	20261019100061: entity.Merchant, // add_profile_to_merchant_distributed_table
+	20261019150020: entity.Merchant, // create_merchant_history_table
+	20261019150021: entity.Merchant, // create_merchant_history_distributed_table
+
+	20231126211331: entity.Budget, // create_budget_table
+	20231126211332: entity.Budget, // create_budget_distributed_table
+	20261019100070: entity.Budget, // add_profile_to_budget_table
+	20261019100071: entity.Budget, // add_profile_to_budget_distributed_table
+	20261019120000: entity.Budget, // rename_budget_table_for_partitioning
+	20261019120001: entity.Budget, // create_partitioned_budget_table
+	20261019120002: entity.Budget, // copy_budget_table_data
+	20261019120003: entity.Budget, // drop_unpartitioned_budget_table
+	20261019220000: entity.Budget, // create_budget_actual_table
+	20261019220001: entity.Budget, // create_budget_actual_distributed_table
+
+	20231126211113: entity.Reminder, // create_reminder_table
+	20231126211114: entity.Reminder, // create_reminder_distributed_table
+	20261019100080: entity.Reminder, // add_profile_to_reminder_table
+	20261019100081: entity.Reminder, // add_profile_to_reminder_distributed_table
+	20261019210000: entity.Reminder, // create_forecast_transaction_table
+	20261019210001: entity.Reminder, // create_forecast_transaction_distributed_table
+
+	20231126211148: entity.ReminderMarker, // create_reminder_marker_table
+	20231126211149: entity.ReminderMarker, // create_reminder_marker_distributed_table
+	20261019100090: entity.ReminderMarker, // add_profile_to_reminder_marker_table
+	20261019100091: entity.ReminderMarker, // add_profile_to_reminder_marker_distributed_table
+	20261019120010: entity.ReminderMarker, // rename_reminder_marker_table_for_partitioning
+	20261019120011: entity.ReminderMarker, // create_partitioned_reminder_marker_table
+	20261019120012: entity.ReminderMarker, // copy_reminder_marker_table_data
+	20261019120013: entity.ReminderMarker, // drop_unpartitioned_reminder_marker_table
+
+	20231126211300: entity.Transaction, // create_transaction_table
+	20231126211301: entity.Transaction, // create_transaction_distributed_table
+	20261019100100: entity.Transaction, // add_profile_to_transaction_table
+	20261019100101: entity.Transaction, // add_profile_to_transaction_distributed_table
+	20261019120020: entity.Transaction, // rename_transaction_table_for_partitioning
+	20261019120021: entity.Transaction, // create_partitioned_transaction_table
+	20261019120022: entity.Transaction, // copy_transaction_table_data
+	20261019120023: entity.Transaction, // drop_unpartitioned_transaction_table
+	20261019130000: entity.Transaction, // add_normalized_amounts_to_transaction_table
+	20261019130001: entity.Transaction, // add_normalized_amounts_to_transaction_distributed_table
+	20261019160000: entity.Transaction, // create_transaction_audit_table
+	20261019160001: entity.Transaction, // create_transaction_audit_distributed_table
+	20261019200010: entity.Transaction, // create_transaction_tag_table
+	20261019200011: entity.Transaction, // create_transaction_tag_distributed_table
+}
@@ -2,329 +2,440 @@ package clickhouse
 
 import (
 	"context"
-	"fmt"
 	"github.com/nemirlev/zenapi"
+	"github.com/nemirlev/zenexport/internal/audit"
+	"github.com/nemirlev/zenexport/internal/currency"
+	"github.com/nemirlev/zenexport/internal/entity"
+	"github.com/nemirlev/zenexport/internal/logger"
+	"github.com/nemirlev/zenexport/internal/planner"
+	"github.com/nemirlev/zenexport/internal/tracing"
+	"time"
 )
 
-// Save сохраняет данные, полученные из объекта zenapi.Response, в соответствующие таблицы базы данных ClickHouse.
-func (s *Store) Save(data *zenapi.Response) error {
-	if s.Conn == nil {
-		if err := s.connect(); err != nil {
-			return err
-		}
-	}
+// Save сохраняет данные профиля profile, полученные из объекта zenapi.Response, в таблицы базы данных ClickHouse,
+// а затем пересчитывает производные таблицы. Сущности, отключенные фильтром INCLUDE_ENTITIES/EXCLUDE_ENTITIES,
+// не сохраняются, независимые таблицы сохраняются параллельно в Config.Workers потоков.
+func (s *Store) Save(ctx context.Context, profile string, data *zenapi.Response) (err error) {
+	ctx, span := tracing.Start(ctx, "clickhouse.save", tracing.AttrProfile.String(profile))
+	defer func() { tracing.End(span, err) }()
 
-	ctx := context.Background()
-
-	defer func() {
-		if err := s.Conn.Close(); err != nil {
-			s.Log.WithError(err, "failed to close connection")
-		}
-	}()
-
-	if err := s.saveInstruments(ctx, data.Instrument); err != nil {
-		return err
-	}
-	if err := s.saveCountries(ctx, data.Country); err != nil {
-		return err
-	}
-	if err := s.saveCompanies(ctx, data.Company); err != nil {
-		return err
-	}
-	if err := s.saveUsers(ctx, data.User); err != nil {
+	if err := s.ensureConnection(ctx); err != nil {
 		return err
 	}
-	if err := s.saveAccounts(ctx, data.Account); err != nil {
-		return err
-	}
-	if err := s.saveTags(ctx, data.Tag); err != nil {
+
+	filter, err := s.filter()
+	if err != nil {
 		return err
 	}
-	if err := s.saveMerchants(ctx, data.Merchant); err != nil {
+	period, err := s.period()
+	if err != nil {
 		return err
 	}
-	if err := s.saveBudgets(ctx, data.Budget); err != nil {
-		return err
+	// Производные таблицы строятся по всем данным, даже если сущности с датой сохраняются только за период
+	full := data
+	data = entity.FilterPeriod(data, period)
+	observedAt := time.Now()
+	versions := entity.Versions(data)
+	// История счетов, категорий и мерчантов ведется только при включенной HISTORY
+	saveHistoryIfEnabled := func(ctx context.Context, name string) error {
+		if !s.Config.History {
+			return nil
+		}
+		return s.saveHistory(ctx, profile, name, versions[name], observedAt)
 	}
-	if err := s.saveReminders(ctx, data.Reminder); err != nil {
-		return err
+
+	plan := planner.New(s.Config.Workers)
+	add := func(name string, run func(ctx context.Context) error, dependsOn ...string) {
+		if !filter.Enabled(name) {
+			// Отключенная сущность остается в плане пустой задачей, чтобы зависимости оставались корректными.
+			s.Log.DebugContext(ctx, "skip saving: entity is disabled", logger.Table, name)
+			run = func(ctx context.Context) error { return nil }
+		}
+		plan.Add(name, run, dependsOn...)
 	}
-	if err := s.saveReminderMarkers(ctx, data.ReminderMarker); err != nil {
-		return err
+
+	add(entity.Instrument, func(ctx context.Context) error {
+		if err := s.saveInstruments(ctx, profile, data.Instrument); err != nil {
+			return err
+		}
+		return s.appendRateHistory(ctx, profile, data.Instrument, observedAt)
+	})
+	add(entity.Country, func(ctx context.Context) error {
+		return s.saveCountries(ctx, profile, data.Country)
+	})
+	add(entity.Company, func(ctx context.Context) error {
+		return s.saveCompanies(ctx, profile, data.Company)
+	}, entity.Country)
+	add(entity.User, func(ctx context.Context) error {
+		return s.saveUsers(ctx, profile, data.User)
+	}, entity.Instrument)
+	add(entity.Account, func(ctx context.Context) error {
+		if err := s.saveAccounts(ctx, profile, data.Account); err != nil {
+			return err
+		}
+		return saveHistoryIfEnabled(ctx, entity.Account)
+	}, entity.Instrument, entity.Company, entity.User)
+	add(entity.Tag, func(ctx context.Context) error {
+		if err := s.saveTags(ctx, profile, data.Tag); err != nil {
+			return err
+		}
+		return saveHistoryIfEnabled(ctx, entity.Tag)
+	}, entity.User)
+	add(entity.Merchant, func(ctx context.Context) error {
+		if err := s.saveMerchants(ctx, profile, data.Merchant); err != nil {
+			return err
+		}
+		return saveHistoryIfEnabled(ctx, entity.Merchant)
+	}, entity.User)
+	add(entity.Budget, func(ctx context.Context) error {
+		return s.saveBudgets(ctx, profile, data.Budget)
+	}, entity.Tag)
+	add(entity.Reminder, func(ctx context.Context) error {
+		return s.saveReminders(ctx, profile, data.Reminder)
+	}, entity.Account, entity.Tag, entity.Merchant)
+	add(entity.ReminderMarker, func(ctx context.Context) error {
+		return s.saveReminderMarkers(ctx, profile, data.ReminderMarker)
+	}, entity.Reminder)
+	add(entity.Transaction, func(ctx context.Context) error {
+		// История курсов ведется вместе с инструментами
+		converter, err := s.converter(ctx, profile, data, filter.Enabled(entity.Instrument))
+		if err != nil {
+			return err
+		}
+		// Прежние значения измененных транзакций нужно прочитать до перезаписи таблицы. Транзакции сравниваются
+		// со всем ответом ZenMoney, чтобы перенос даты за границу периода не считался удалением
+		stored, err := s.auditedTransactions(ctx, profile, full.Transaction, period)
+		if err != nil {
+			s.Log.WithErrorContext(ctx, err, "failed to read transactions for audit", logger.Table, entity.Transaction)
+			return err
+		}
+		if err := s.saveTransactions(ctx, profile, data.Transaction, converter); err != nil {
+			return err
+		}
+		return s.saveAudit(ctx, profile, audit.Compare(stored, full.Transaction, observedAt), observedAt)
+	}, entity.Instrument, entity.Account, entity.Tag, entity.Merchant, entity.ReminderMarker)
+
+	// Производная таблица вычисляется по всем данным профиля и перезаписывается целиком. Она сохраняется
+	// после таблиц сущностей, из которых вычисляется, и только если все они включены фильтром.
+	derive := func(name string, run func(ctx context.Context) error, sources ...string) {
+		for _, source := range sources {
+			if !filter.Enabled(source) {
+				s.Log.DebugContext(ctx, "skip saving: source entity is disabled", logger.Table, name, "source", source)
+				return
+			}
+		}
+		plan.Add(name, run, sources...)
 	}
-	if err := s.saveTransactions(ctx, data.Transaction); err != nil {
+
+	derive(balanceDailyTable, func(ctx context.Context) error {
+		return s.saveDailyBalances(ctx, profile, full, observedAt)
+	}, entity.Account, entity.Transaction)
+	// Виды транзакций определяются по всем транзакциям, чтобы журнал не зависел от периода SINCE/UNTIL
+	derive(ledgerEntryTable, func(ctx context.Context) error {
+		return s.saveLedgerEntries(ctx, profile, full)
+	}, entity.Transaction)
+	derive(tagPathTable, func(ctx context.Context) error {
+		return s.saveTagPaths(ctx, profile, data.Tag)
+	}, entity.Tag)
+	derive(transactionTagTable, func(ctx context.Context) error {
+		return s.saveTransactionTags(ctx, profile, full.Transaction)
+	}, entity.Transaction)
+	// Отметки напоминаний нужны за все время, чтобы сопоставить их с плановыми операциями
+	derive(forecastTable, func(ctx context.Context) error {
+		return s.saveForecast(ctx, profile, full, observedAt)
+	}, entity.Reminder)
+	derive(budgetActualTable, func(ctx context.Context) error {
+		converter, err := s.converter(ctx, profile, full, filter.Enabled(entity.Instrument))
+		if err != nil {
+			return err
+		}
+		return s.saveBudgetActual(ctx, profile, full, converter)
+	}, entity.Budget, entity.Transaction)
+
+	if err := plan.Run(ctx); err != nil {
+		s.Log.WithErrorContext(ctx, err, "failed to save data to clickhouse")
 		return err
 	}
-
 	return nil
 }
 
 // saveBatch выполняет пакетное сохранение данных в указанную таблицу базы данных ClickHouse.
+// Перед вставкой удаляются только строки профиля profile, данные других профилей не затрагиваются.
+// Если задан период SINCE/UNTIL, из таблиц сущностей с датой удаляются только строки за этот период.
+// Последним столбцом запроса должен быть profile, его значение добавляется к каждой строке.
 // Параметры:
 // - ctx: контекст для управления временем выполнения и отменой запроса.
+// - profile: метка профиля, которой помечаются строки.
 // - tableName: имя таблицы, в которую будут вставлены данные.
 // - query: строка с SQL-запросом для выполнения пакетной вставки данных.
-// - data: срез с данными, которые будут вставлены в таблицу.
-func (s *Store) saveBatch(ctx context.Context, tableName string, query string, data [][]interface{}) error {
-	fmt.Printf("Starting to save %d rows into %s...\n", len(data), tableName)
-	if err := s.truncateTable(ctx, tableName); err != nil {
-		s.Log.WithError(err, "failed to truncate table %s", tableName)
+// - total: количество строк, которые будут вставлены в таблицу.
+// - row: функция, возвращающая строку для вставки по ее индексу.
+func (s *Store) saveBatch(ctx context.Context, profile string, tableName string, query string, total int, row rowFunc) (err error) {
+	start := time.Now()
+	ctx, span := tracing.Start(ctx, "clickhouse.write "+tableName,
+		tracing.AttrTable.String(tableName),
+		tracing.AttrRows.Int(total),
+	)
+	ctx, ids := withQueryIDs(ctx)
+	defer func() {
+		span.SetAttributes(tracing.AttrQueryIDs.StringSlice(ids.ids))
+		tracing.End(span, err)
+	}()
+
+	if err := s.deleteRows(ctx, tableName, profile); err != nil {
+		s.Log.WithErrorContext(ctx, err, "failed to delete profile data", logger.Table, tableName)
 		return err
 	}
 
-	if err := s.executeBatch(ctx, query, data); err != nil {
-		s.Log.WithError(err, "failed to execute batch for table %s", tableName)
+	withProfile := func(i int) []interface{} {
+		return append(row(i), profile)
+	}
+	if err := s.executeBatch(ctx, tableName, query, total, withProfile); err != nil {
+		s.Log.WithErrorContext(ctx, err, "failed to execute batch", logger.Table, tableName)
 		return err
 	}
-	fmt.Printf("Finished saving %d rows into %s.\n", len(data), tableName)
+	s.Log.InfoContext(ctx, "saved table", logger.Table, tableName, logger.Rows, total, logger.Duration, time.Since(start))
 	return nil
 }
 
 // saveTransactions сохраняет транзакции в таблицу transaction базы данных ClickHouse.
-func (s *Store) saveTransactions(ctx context.Context, transactions []zenapi.Transaction) error {
+// Доход и расход дополнительно сохраняются в основной валюте пользователя (currency, income_normalized,
+// outcome_normalized). Если курс валюты неизвестен, эти столбцы остаются пустыми.
+func (s *Store) saveTransactions(ctx context.Context, profile string, transactions []zenapi.Transaction, converter *currency.Converter) error {
 	query := `
 		INSERT INTO transaction (
 			id, changed, created, user, deleted, hold, income_instrument, income_account, 
 			income, outcome_instrument, outcome_account, outcome, tag, merchant, payee, 
 			original_payee, comment, date, mcc, reminder_marker, op_income, op_income_instrument, 
-			op_outcome, op_outcome_instrument, latitude, longitude
+			op_outcome, op_outcome_instrument, latitude, longitude, currency, income_normalized,
+			outcome_normalized, profile
 		) VALUES (
-			?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+			?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
 		)
 	`
 
-	var data [][]interface{}
-	for _, transaction := range transactions {
-		data = append(data, []interface{}{
+	unconverted := 0
+	err := s.saveBatch(ctx, profile, "transaction", query, len(transactions), func(i int) []interface{} {
+		transaction := transactions[i]
+		var userCurrency *int
+		var income, outcome *float64
+		if amounts, err := converter.Transaction(transaction); err == nil {
+			userCurrency, income, outcome = &amounts.Currency, &amounts.Income, &amounts.Outcome
+		} else {
+			unconverted++
+		}
+		return []interface{}{
 			transaction.ID, transaction.Changed, transaction.Created, transaction.User, transaction.Deleted,
 			transaction.Hold, transaction.IncomeInstrument, transaction.IncomeAccount, transaction.Income,
 			transaction.OutcomeInstrument, transaction.OutcomeAccount, transaction.Outcome, transaction.Tag,
 			transaction.Merchant, transaction.Payee, transaction.OriginalPayee, transaction.Comment,
 			transaction.Date, transaction.Mcc, transaction.ReminderMarker, transaction.OpIncome,
 			transaction.OpIncomeInstrument, transaction.OpOutcome, transaction.OpOutcomeInstrument,
-			transaction.Latitude, transaction.Longitude,
-		})
+			transaction.Latitude, transaction.Longitude, userCurrency, income, outcome,
+		}
+	})
+	if unconverted > 0 {
+		s.Log.DebugContext(ctx, "transactions without normalized amounts", logger.Table, "transaction", logger.Rows, unconverted)
 	}
-
-	return s.saveBatch(ctx, "transaction", query, data)
+	return err
 }
 
 // saveReminderMarkers сохраняет маркеры напоминаний в таблицу reminder_marker базы данных ClickHouse.
-func (s *Store) saveReminderMarkers(ctx context.Context, markers []zenapi.ReminderMarker) error {
+func (s *Store) saveReminderMarkers(ctx context.Context, profile string, markers []zenapi.ReminderMarker) error {
 	query := `
 		INSERT INTO reminder_marker (
 			id, changed, user, income_instrument, income_account, income, outcome_instrument, 
-			outcome_account, outcome, tag, merchant, payee, comment, date, reminder, state, notify
+			outcome_account, outcome, tag, merchant, payee, comment, date, reminder, state, notify, profile
 		) VALUES (
-			?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+			?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
 		)
 	`
 
-	var data [][]interface{}
-	for _, marker := range markers {
-		data = append(data, []interface{}{
+	return s.saveBatch(ctx, profile, "reminder_marker", query, len(markers), func(i int) []interface{} {
+		marker := markers[i]
+		return []interface{}{
 			marker.ID, marker.Changed, marker.User, marker.IncomeInstrument, marker.IncomeAccount,
 			marker.Income, marker.OutcomeInstrument, marker.OutcomeAccount, marker.Outcome, marker.Tag,
 			marker.Merchant, marker.Payee, marker.Comment, marker.Date, marker.Reminder,
 			marker.State, marker.Notify,
-		})
-	}
-
-	return s.saveBatch(ctx, "reminder_marker", query, data)
+		}
+	})
 }
 
 // saveReminders сохраняет напоминания в таблицу reminder базы данных ClickHouse.
-func (s *Store) saveReminders(ctx context.Context, reminders []zenapi.Reminder) error {
+func (s *Store) saveReminders(ctx context.Context, profile string, reminders []zenapi.Reminder) error {
 	query := `
 		INSERT INTO reminder (
 			id, changed, user, income_instrument, income_account, income, outcome_instrument, 
 			outcome_account, outcome, tag, merchant, payee, comment, interval, step, points, 
-			start_date, end_date, notify
+			start_date, end_date, notify, profile
 		) VALUES (
-			?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+			?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
 		)
 	`
 
-	var data [][]interface{}
-	for _, reminder := range reminders {
-		data = append(data, []interface{}{
+	return s.saveBatch(ctx, profile, "reminder", query, len(reminders), func(i int) []interface{} {
+		reminder := reminders[i]
+		return []interface{}{
 			reminder.ID, reminder.Changed, reminder.User, reminder.IncomeInstrument, reminder.IncomeAccount,
 			reminder.Income, reminder.OutcomeInstrument, reminder.OutcomeAccount, reminder.Outcome,
 			reminder.Tag, reminder.Merchant, reminder.Payee, reminder.Comment, reminder.Interval, reminder.Step,
 			reminder.Points, reminder.StartDate, reminder.EndDate, reminder.Notify,
-		})
-	}
-
-	return s.saveBatch(ctx, "reminder", query, data)
+		}
+	})
 }
 
 // saveBudgets сохраняет бюджеты в таблицу budget базы данных ClickHouse.
-func (s *Store) saveBudgets(ctx context.Context, budgets []zenapi.Budget) error {
+func (s *Store) saveBudgets(ctx context.Context, profile string, budgets []zenapi.Budget) error {
 	query := `
 		INSERT INTO budget (
-			changed, user, tag, date, income, income_lock, outcome, outcome_lock
+			changed, user, tag, date, income, income_lock, outcome, outcome_lock, profile
 		) VALUES (
-			?, ?, ?, ?, ?, ?, ?, ?
+			?, ?, ?, ?, ?, ?, ?, ?, ?
 		)
 	`
 
-	var data [][]interface{}
-	for _, budget := range budgets {
-		data = append(data, []interface{}{
+	return s.saveBatch(ctx, profile, "budget", query, len(budgets), func(i int) []interface{} {
+		budget := budgets[i]
+		return []interface{}{
 			budget.Changed, budget.User, budget.Tag, budget.Date,
 			budget.Income, budget.IncomeLock, budget.Outcome, budget.OutcomeLock,
-		})
-	}
-
-	return s.saveBatch(ctx, "budget", query, data)
+		}
+	})
 }
 
 // saveMerchants сохраняет мерчантов в таблицу merchant базы данных ClickHouse.
-func (s *Store) saveMerchants(ctx context.Context, merchants []zenapi.Merchant) error {
+func (s *Store) saveMerchants(ctx context.Context, profile string, merchants []zenapi.Merchant) error {
 	query := `
 		INSERT INTO merchant (
-			id, changed, user, title
+			id, changed, user, title, profile
 		) VALUES (
-			?, ?, ?, ?
+			?, ?, ?, ?, ?
 		)
 	`
 
-	var data [][]interface{}
-	for _, merchant := range merchants {
-		data = append(data, []interface{}{
+	return s.saveBatch(ctx, profile, "merchant", query, len(merchants), func(i int) []interface{} {
+		merchant := merchants[i]
+		return []interface{}{
 			merchant.ID, merchant.Changed, merchant.User, merchant.Title,
-		})
-	}
-
-	return s.saveBatch(ctx, "merchant", query, data)
+		}
+	})
 }
 
 // saveTags сохраняет теги в таблицу tag базы данных ClickHouse.
-func (s *Store) saveTags(ctx context.Context, tags []zenapi.Tag) error {
+func (s *Store) saveTags(ctx context.Context, profile string, tags []zenapi.Tag) error {
 	query := `
 		INSERT INTO tag (
 			id, changed, user, title, parent, icon, picture, color, show_income, 
-			show_outcome, budget_income, budget_outcome, required
+			show_outcome, budget_income, budget_outcome, required, profile
 		) VALUES (
-			?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+			?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
 		)
 	`
 
-	var data [][]interface{}
-	for _, tag := range tags {
-		data = append(data, []interface{}{
+	return s.saveBatch(ctx, profile, "tag", query, len(tags), func(i int) []interface{} {
+		tag := tags[i]
+		return []interface{}{
 			tag.ID, tag.Changed, tag.User, tag.Title, tag.Parent, tag.Icon,
 			tag.Picture, tag.Color, tag.ShowIncome, tag.ShowOutcome,
 			tag.BudgetIncome, tag.BudgetOutcome, tag.Required,
-		})
-	}
-
-	return s.saveBatch(ctx, "tag", query, data)
+		}
+	})
 }
 
 // saveAccounts сохраняет счета в таблицу account базы данных ClickHouse.
-func (s *Store) saveAccounts(ctx context.Context, accounts []zenapi.Account) error {
+func (s *Store) saveAccounts(ctx context.Context, profile string, accounts []zenapi.Account) error {
 	query := `
 		INSERT INTO account (
 			id, changed, user, role, instrument, company, type, title, sync_id, balance, 
 			start_balance, credit_limit, in_balance, savings, enable_correction, enable_sms, 
 			archive, capitalization, percent, start_date, end_date_offset, 
-			end_date_offset_interval, payoff_step, payoff_interval
+			end_date_offset_interval, payoff_step, payoff_interval, profile
 		) VALUES (
-			?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
+			?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?
 		)
 	`
 
-	var data [][]interface{}
-	for _, account := range accounts {
-		data = append(data, []interface{}{
+	return s.saveBatch(ctx, profile, "account", query, len(accounts), func(i int) []interface{} {
+		account := accounts[i]
+		return []interface{}{
 			account.ID, account.Changed, account.User, account.Role, account.Instrument, account.Company,
 			account.Type, account.Title, account.SyncID, account.Balance, account.StartBalance, account.CreditLimit,
 			account.InBalance, account.Savings, account.EnableCorrection, account.EnableSMS, account.Archive,
 			account.Capitalization, account.Percent, account.StartDate, account.EndDateOffset,
 			account.EndDateOffsetInterval, account.PayoffStep, account.PayoffInterval,
-		})
-	}
-
-	return s.saveBatch(ctx, "account", query, data)
+		}
+	})
 }
 
 // saveUsers сохраняет пользователей в таблицу user базы данных ClickHouse.
-func (s *Store) saveUsers(ctx context.Context, users []zenapi.User) error {
+func (s *Store) saveUsers(ctx context.Context, profile string, users []zenapi.User) error {
 	query := `
 		INSERT INTO user (
-			id, changed, login, currency, parent
+			id, changed, login, currency, parent, profile
 		) VALUES (
-			?, ?, ?, ?, ?
+			?, ?, ?, ?, ?, ?
 		)
 	`
 
-	var data [][]interface{}
-	for _, user := range users {
-		data = append(data, []interface{}{
+	return s.saveBatch(ctx, profile, "user", query, len(users), func(i int) []interface{} {
+		user := users[i]
+		return []interface{}{
 			user.ID, user.Changed, user.Login, user.Currency, user.Parent,
-		})
-	}
-
-	return s.saveBatch(ctx, "user", query, data)
+		}
+	})
 }
 
 // saveCompanies сохраняет компании в таблицу company базы данных ClickHouse.
-func (s *Store) saveCompanies(ctx context.Context, companies []zenapi.Company) error {
+func (s *Store) saveCompanies(ctx context.Context, profile string, companies []zenapi.Company) error {
 	query := `
 		INSERT INTO company (
-			id, changed, title, full_title, www, country
+			id, changed, title, full_title, www, country, profile
 		) VALUES (
-			?, ?, ?, ?, ?, ?
+			?, ?, ?, ?, ?, ?, ?
 		)
 	`
 
-	var data [][]interface{}
-	for _, company := range companies {
-		data = append(data, []interface{}{
+	return s.saveBatch(ctx, profile, "company", query, len(companies), func(i int) []interface{} {
+		company := companies[i]
+		return []interface{}{
 			company.ID, company.Changed, company.Title, company.FullTitle, company.Www, company.Country,
-		})
-	}
-
-	return s.saveBatch(ctx, "company", query, data)
+		}
+	})
 }
 
 // saveCountries сохраняет страны в таблицу country базы данных ClickHouse.
-func (s *Store) saveCountries(ctx context.Context, countries []zenapi.Country) error {
+func (s *Store) saveCountries(ctx context.Context, profile string, countries []zenapi.Country) error {
 	query := `
 		INSERT INTO country (
-			id, title, currency, domain
+			id, title, currency, domain, profile
 		) VALUES (
-			?, ?, ?, ?
+			?, ?, ?, ?, ?
 		)
 	`
 
-	var data [][]interface{}
-	for _, country := range countries {
-		data = append(data, []interface{}{
+	return s.saveBatch(ctx, profile, "country", query, len(countries), func(i int) []interface{} {
+		country := countries[i]
+		return []interface{}{
 			country.ID, country.Title, country.Currency, country.Domain,
-		})
-	}
-
-	return s.saveBatch(ctx, "country", query, data)
+		}
+	})
 }
 
 // saveInstruments сохраняет валютные инструменты в таблицу instrument базы данных ClickHouse.
-func (s *Store) saveInstruments(ctx context.Context, instruments []zenapi.Instrument) error {
+func (s *Store) saveInstruments(ctx context.Context, profile string, instruments []zenapi.Instrument) error {
 	query := `
 		INSERT INTO instrument (
-			id, changed, title, short_title, symbol, rate
+			id, changed, title, short_title, symbol, rate, profile
 		) VALUES (
-			?, ?, ?, ?, ?, ?
+			?, ?, ?, ?, ?, ?, ?
 		)
 	`
 
-	var data [][]interface{}
-	for _, instrument := range instruments {
-		data = append(data, []interface{}{
+	return s.saveBatch(ctx, profile, "instrument", query, len(instruments), func(i int) []interface{} {
+		instrument := instruments[i]
+		return []interface{}{
 			instrument.ID, instrument.Changed, instrument.Title, instrument.ShortTitle, instrument.Symbol, instrument.Rate,
-		})
-	}
-
-	return s.saveBatch(ctx, "instrument", query, data)
+		}
+	})
 }
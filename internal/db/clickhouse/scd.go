@@ -0,0 +1,72 @@
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+	"github.com/nemirlev/zenexport/internal/entity"
+	"github.com/nemirlev/zenexport/internal/logger"
+	"time"
+)
+
+// historySuffix суффикс таблиц истории изменений (SCD type 2).
+const historySuffix = "_history"
+
+// historyColumns столбцы таблиц сущностей, которые копируются в таблицы истории.
+var historyColumns = map[string]string{
+	entity.Account: `id, changed, user, role, instrument, company, type, title, sync_id, balance,
+		start_balance, credit_limit, in_balance, savings, enable_correction, enable_sms,
+		archive, capitalization, percent, start_date, end_date_offset,
+		end_date_offset_interval, payoff_step, payoff_interval, profile`,
+	entity.Tag: `id, changed, user, title, parent, icon, picture, color, show_income,
+		show_outcome, budget_income, budget_outcome, required, profile`,
+	entity.Merchant: `id, changed, user, title, profile`,
+}
+
+// saveHistory ведет историю изменений сущности tableName в таблице <tableName>_history. Версии строк из ZenMoney
+// incoming сравниваются по полю changed с открытыми (valid_to IS NULL) версиями истории: у измененных и удаленных
+// строк версия закрывается моментом observedAt, а новые и измененные строки копируются из только что сохраненной
+// таблицы сущности как открытые версии с valid_from = observedAt.
+func (s *Store) saveHistory(ctx context.Context, profile string, tableName string, incoming map[string]string, observedAt time.Time) error {
+	conn, err := s.conn()
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	table := tableName + historySuffix
+
+	query := fmt.Sprintf("SELECT toString(id), toString(changed) FROM %s WHERE profile = ? AND valid_to IS NULL", table)
+	stored, err := s.queryVersions(queryContext(ctx), query, profile)
+	if err != nil {
+		s.Log.WithErrorContext(ctx, err, "failed to read history", logger.Table, table)
+		return err
+	}
+	changes := entity.Diff(stored, incoming)
+
+	if closed := append(append([]string{}, changes.Changed...), changes.Removed...); len(closed) > 0 {
+		query := fmt.Sprintf(
+			"ALTER TABLE %s%s UPDATE valid_to = ? WHERE profile = ? AND valid_to IS NULL AND has(?, toString(id))",
+			s.localTable(table), s.onCluster(),
+		)
+		if err := conn.Exec(queryContext(mutationContext(ctx)), query, observedAt, profile, closed); err != nil {
+			s.Log.WithErrorContext(ctx, err, "failed to close history versions", logger.Table, table)
+			return err
+		}
+	}
+
+	if opened := append(append([]string{}, changes.Added...), changes.Changed...); len(opened) > 0 {
+		columns := historyColumns[tableName]
+		query := fmt.Sprintf(
+			"INSERT INTO %s (%s, valid_from) SELECT %s, ? FROM %s WHERE profile = ? AND has(?, toString(id))",
+			table, columns, columns, tableName,
+		)
+		if err := conn.Exec(queryContext(ctx), query, observedAt, profile, opened); err != nil {
+			s.Log.WithErrorContext(ctx, err, "failed to save history versions", logger.Table, table)
+			return err
+		}
+	}
+
+	s.Log.InfoContext(ctx, "saved history", logger.Table, table, "added", len(changes.Added),
+		"changed", len(changes.Changed), "removed", len(changes.Removed), logger.Duration, time.Since(start))
+	return nil
+}
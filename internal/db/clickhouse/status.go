@@ -0,0 +1,114 @@
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+	"github.com/nemirlev/zenexport/internal/db/model"
+	"github.com/nemirlev/zenexport/internal/entity"
+)
+
+// RecordRun сохраняет результат запуска синхронизации в таблицу sync_run.
+func (s *Store) RecordRun(ctx context.Context, run model.Run) error {
+	if err := s.ensureConnection(ctx); err != nil {
+		return err
+	}
+	conn, err := s.conn()
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO sync_run (
+			run_id, profile, started_at, finished_at, status, error, rows
+		) VALUES (
+			?, ?, ?, ?, ?, ?, ?
+		)
+	`
+	return conn.Exec(ctx, query, run.ID, run.Profile, run.StartedAt, run.FinishedAt, run.Status, run.Error, run.Rows)
+}
+
+// Status возвращает последний запуск каждого профиля и количество строк по профилям в таблицах
+// сущностей, включенных фильтром INCLUDE_ENTITIES/EXCLUDE_ENTITIES.
+func (s *Store) Status(ctx context.Context) (*model.Status, error) {
+	if err := s.ensureConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	runs, err := s.lastRuns(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := s.filter()
+	if err != nil {
+		return nil, err
+	}
+
+	status := &model.Status{Runs: runs}
+	for _, e := range entity.All {
+		if !filter.Enabled(e) {
+			continue
+		}
+		rows, err := s.countRows(ctx, e)
+		if err != nil {
+			return nil, err
+		}
+		status.Rows = append(status.Rows, rows...)
+	}
+	return status, nil
+}
+
+// lastRuns возвращает последний запуск каждого профиля.
+func (s *Store) lastRuns(ctx context.Context) ([]model.Run, error) {
+	conn, err := s.conn()
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT toString(run_id), profile, started_at, finished_at, status, error, rows
+		FROM sync_run
+		ORDER BY started_at DESC
+		LIMIT 1 BY profile
+	`
+	rows, err := conn.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []model.Run
+	for rows.Next() {
+		var run model.Run
+		if err := rows.Scan(&run.ID, &run.Profile, &run.StartedAt, &run.FinishedAt, &run.Status, &run.Error, &run.Rows); err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
+
+// countRows возвращает количество строк таблицы по профилям.
+func (s *Store) countRows(ctx context.Context, tableName string) ([]model.TableRows, error) {
+	conn, err := s.conn()
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf("SELECT profile, count() FROM %s GROUP BY profile ORDER BY profile", tableName)
+	rows, err := conn.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []model.TableRows
+	for rows.Next() {
+		r := model.TableRows{Table: tableName}
+		if err := rows.Scan(&r.Profile, &r.Rows); err != nil {
+			return nil, err
+		}
+		result = append(result, r)
+	}
+	return result, rows.Err()
+}
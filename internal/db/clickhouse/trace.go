@@ -0,0 +1,35 @@
+package clickhouse
+
+import (
+	"context"
+	"github.com/ClickHouse/clickhouse-go/v2"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type queryIDsKey struct{}
+
+// queryIDs собирает идентификаторы запросов ClickHouse, выполненных при записи одной таблицы.
+// Запросы одной таблицы выполняются последовательно, поэтому блокировка не нужна.
+type queryIDs struct {
+	ids []string
+}
+
+// withQueryIDs возвращает контекст, в котором queryContext будет сохранять идентификаторы запросов.
+func withQueryIDs(ctx context.Context) (context.Context, *queryIDs) {
+	ids := &queryIDs{}
+	return context.WithValue(ctx, queryIDsKey{}, ids), ids
+}
+
+// queryContext задает запросу идентификатор и передает в ClickHouse контекст текущего спана,
+// чтобы запрос можно было найти в system.query_log и связать с трассировкой запуска.
+func queryContext(ctx context.Context) context.Context {
+	queryID := uuid.NewString()
+	if ids, ok := ctx.Value(queryIDsKey{}).(*queryIDs); ok {
+		ids.ids = append(ids.ids, queryID)
+	}
+	return clickhouse.Context(ctx,
+		clickhouse.WithQueryID(queryID),
+		clickhouse.WithSpan(trace.SpanContextFromContext(ctx)),
+	)
+}
@@ -1,7 +1,9 @@
 package clickhouse
 
+import "context"
+
 // Update обновляет данные в ClickHouse.
-func (s *Store) Update(data interface{}) error {
+func (s *Store) Update(ctx context.Context, data interface{}) error {
 	// TODO: Требуется разработать, по остаточному принципу, так как обновление в ClickHouse не тривиально
 	panic("implement me")
 }
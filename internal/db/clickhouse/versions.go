@@ -0,0 +1,99 @@
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+	"github.com/nemirlev/zenexport/internal/entity"
+)
+
+// versionColumns выражения ключа и версии строки для каждой таблицы. Они должны давать те же строки,
+// что и entity.Versions для данных ZenMoney.
+var versionColumns = map[string][2]string{
+	entity.Instrument:     {"toString(id)", "toString(changed)"},
+	entity.Country:        {"toString(id)", "concat(title, '|', toString(currency), '|', domain)"},
+	entity.Company:        {"toString(id)", "toString(changed)"},
+	entity.User:           {"toString(id)", "toString(changed)"},
+	entity.Account:        {"toString(id)", "toString(changed)"},
+	entity.Tag:            {"toString(id)", "toString(changed)"},
+	entity.Merchant:       {"toString(id)", "toString(changed)"},
+	entity.Budget:         {"concat(toString(user), '/', ifNull(toString(tag), ''), '/', date)", "toString(changed)"},
+	entity.Reminder:       {"toString(id)", "toString(changed)"},
+	entity.ReminderMarker: {"toString(id)", "toString(changed)"},
+	entity.Transaction:    {"toString(id)", "toString(changed)"},
+}
+
+// Versions возвращает версии строк профиля profile в таблицах сущностей, включенных фильтром
+// INCLUDE_ENTITIES/EXCLUDE_ENTITIES. Строки сущностей с датой ограничиваются периодом SINCE/UNTIL.
+// Ничего не записывает в базу данных.
+func (s *Store) Versions(ctx context.Context, profile string) (map[string]map[string]string, error) {
+	if err := s.ensureConnection(ctx); err != nil {
+		return nil, err
+	}
+
+	filter, err := s.filter()
+	if err != nil {
+		return nil, err
+	}
+
+	period, err := s.period()
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make(map[string]map[string]string, len(entity.All))
+	for _, e := range entity.All {
+		if !filter.Enabled(e) {
+			continue
+		}
+		tablePeriod := entity.Period{}
+		if entity.IsDated(e) {
+			tablePeriod = period
+		}
+		tableVersions, err := s.tableVersions(ctx, e, profile, tablePeriod)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read versions from %s: %w", e, err)
+		}
+		versions[e] = tableVersions
+	}
+	return versions, nil
+}
+
+// tableVersions возвращает версии строк профиля в одной таблице за период period.
+func (s *Store) tableVersions(ctx context.Context, tableName string, profile string, period entity.Period) (map[string]string, error) {
+	columns := versionColumns[tableName]
+	query := fmt.Sprintf("SELECT %s, %s FROM %s WHERE profile = ?", columns[0], columns[1], tableName)
+	args := []interface{}{profile}
+	if !period.Since.IsZero() {
+		query += " AND date >= ?"
+		args = append(args, period.Since.Format(entity.DateLayout))
+	}
+	if !period.Until.IsZero() {
+		query += " AND date <= ?"
+		args = append(args, period.Until.Format(entity.DateLayout))
+	}
+	return s.queryVersions(ctx, query, args...)
+}
+
+// queryVersions выполняет запрос, возвращающий ключ и версию строк, и собирает результат в map.
+func (s *Store) queryVersions(ctx context.Context, query string, args ...interface{}) (map[string]string, error) {
+	conn, err := s.conn()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := conn.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := map[string]string{}
+	for rows.Next() {
+		var key, version string
+		if err := rows.Scan(&key, &version); err != nil {
+			return nil, err
+		}
+		versions[key] = version
+	}
+	return versions, rows.Err()
+}
@@ -1,12 +1,29 @@
 package db
 
 import (
+	"context"
 	"github.com/nemirlev/zenapi"
+	"github.com/nemirlev/zenexport/internal/db/model"
 )
 
 // DataStore это интерфейс для базы данных. Методы специфичны для работы с данными ДзенМани.
+// Данные каждого профиля ZenMoney сохраняются с его меткой и перезаписываются независимо от других профилей.
+// Соединение открывается один раз через Open, переиспользуется между запусками и закрывается через Close.
 type DataStore interface {
-	Save(data *zenapi.Response) error
-	Update(data interface{}) error
-	Delete(data *zenapi.Deletion) error
+	Open(ctx context.Context) error
+	Close() error
+	Ping(ctx context.Context) error
+	Migrate(ctx context.Context) error
+	Save(ctx context.Context, profile string, data *zenapi.Response) error
+	Update(ctx context.Context, data interface{}) error
+	Delete(ctx context.Context, data *zenapi.Deletion) error
+	// RecordRun сохраняет результат запуска синхронизации.
+	RecordRun(ctx context.Context, run model.Run) error
+	// Status возвращает последние запуски и количество строк в таблицах.
+	Status(ctx context.Context) (*model.Status, error)
+	// Versions возвращает версии сохраненных строк профиля по ключам для каждой включенной сущности.
+	// Ключи и версии строятся так же, как в entity.Versions.
+	Versions(ctx context.Context, profile string) (map[string]map[string]string, error)
+	// SaveReconciliation сохраняет результаты сверки остатков счетов профиля вместо предыдущих.
+	SaveReconciliation(ctx context.Context, profile string, results []model.Reconciliation) error
 }
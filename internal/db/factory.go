@@ -7,19 +7,34 @@ import (
 	"github.com/nemirlev/zenexport/internal/logger"
 )
 
-// NewDataStore фабрика для создания экземпляра DataStore в зависимости от типа базы данных, указанного в конфигурации.
-func NewDataStore(cfg *config.Config, log logger.Log) (DataStore, error) {
-	switch cfg.DatabaseType {
-	case "clickhouse":
-		// Инициализация и конфигурация для ClickHouse
+// factory создает хранилище для конкретного типа базы данных.
+type factory func(cfg *config.Config, log logger.Log) DataStore
+
+var factories = map[string]factory{}
+
+// register регистрирует тип базы данных: фабрику хранилища и схему его настроек для проверки конфигурации.
+func register(name string, schema config.Backend, create factory) {
+	factories[name] = create
+	config.RegisterBackend(name, schema)
+}
+
+func init() {
+	register("clickhouse", clickhouse.ConfigBackend, func(cfg *config.Config, log logger.Log) DataStore {
 		return &clickhouse.Store{
 			Log:    log,
 			Config: cfg,
-		}, nil
-	//case "postgres":
-	//	// Инициализация и конфигурация для PostgreSQL
-	//	return &postgres.PostgresStore{}, nil
-	default:
+		}
+	})
+	//register("postgres", postgres.ConfigBackend, func(cfg *config.Config, log logger.Log) DataStore {
+	//	return &postgres.PostgresStore{}
+	//})
+}
+
+// NewDataStore фабрика для создания экземпляра DataStore в зависимости от типа базы данных, указанного в конфигурации.
+func NewDataStore(cfg *config.Config, log logger.Log) (DataStore, error) {
+	create, ok := factories[cfg.DatabaseType]
+	if !ok {
 		return nil, fmt.Errorf("unsupported database type: %s", cfg.DatabaseType)
 	}
+	return create(cfg, log), nil
 }
@@ -0,0 +1,76 @@
+// Package model содержит типы, которыми команды обмениваются с хранилищами DataStore.
+// Типы вынесены в отдельный пакет, чтобы их могли использовать и пакет db, и реализации хранилищ.
+package model
+
+import "time"
+
+// Статусы запуска синхронизации.
+const (
+	RunSuccess = "success"
+	RunFailed  = "failed"
+)
+
+// Run описывает один запуск синхронизации профиля.
+type Run struct {
+	ID         string
+	Profile    string
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Status     string
+	Error      string
+	// Rows количество полученных строк по таблицам.
+	Rows map[string]uint64
+}
+
+// Duration возвращает длительность запуска.
+func (r Run) Duration() time.Duration {
+	return r.FinishedAt.Sub(r.StartedAt)
+}
+
+// TableRows количество строк профиля в таблице.
+type TableRows struct {
+	Table   string
+	Profile string
+	Rows    uint64
+}
+
+// Status состояние базы данных: последний запуск каждого профиля и количество строк в таблицах.
+type Status struct {
+	Runs []Run
+	Rows []TableRows
+}
+
+// TableDiff изменения, которые внесет синхронизация профиля в таблицу.
+type TableDiff struct {
+	Table     string `json:"table"`
+	Profile   string `json:"profile"`
+	Inserts   uint64 `json:"inserts"`
+	Updates   uint64 `json:"updates"`
+	Deletes   uint64 `json:"deletes"`
+	Unchanged uint64 `json:"unchanged"`
+}
+
+// Changed возвращает true, если в таблице что-то изменится.
+func (d TableDiff) Changed() bool {
+	return d.Inserts+d.Updates+d.Deletes > 0
+}
+
+// Результаты сверки остатка счета.
+const (
+	ReconciliationOK       = "ok"
+	ReconciliationMismatch = "mismatch"
+)
+
+// Reconciliation результат сверки остатка счета в ZenMoney с остатком, вычисленным по транзакциям.
+type Reconciliation struct {
+	Account    string
+	Title      string
+	Instrument *int
+	// Balance остаток счета в ZenMoney.
+	Balance float64
+	// Computed остаток, вычисленный как start_balance плюс все движения по счету.
+	Computed   float64
+	Difference float64
+	Tolerance  float64
+	Status     string
+}
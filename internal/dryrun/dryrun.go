@@ -0,0 +1,66 @@
+// Package dryrun вычисляет, что изменит синхронизация в базе данных, ничего в нее не записывая.
+package dryrun
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/nemirlev/zenexport/internal/db/model"
+	"github.com/nemirlev/zenexport/internal/entity"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// Report отчет пробного запуска.
+type Report struct {
+	GeneratedAt time.Time         `json:"generated_at"`
+	Tables      []model.TableDiff `json:"tables"`
+}
+
+// Compare сравнивает версии строк из ZenMoney incoming с версиями в базе данных stored для таблиц tables
+// через entity.Diff. Добавленные строки считаются вставками, измененные - обновлениями, а пропавшие - удалениями.
+func Compare(profile string, tables []string, incoming, stored map[string]map[string]string) []model.TableDiff {
+	diffs := make([]model.TableDiff, 0, len(tables))
+	for _, table := range tables {
+		changes := entity.Diff(stored[table], incoming[table])
+		diffs = append(diffs, model.TableDiff{
+			Table:     table,
+			Profile:   profile,
+			Inserts:   uint64(len(changes.Added)),
+			Updates:   uint64(len(changes.Changed)),
+			Deletes:   uint64(len(changes.Removed)),
+			Unchanged: uint64(len(incoming[table]) - len(changes.Added) - len(changes.Changed)),
+		})
+	}
+	return diffs
+}
+
+// Print выводит изменения по таблицам и итоговую строку.
+func Print(w io.Writer, diffs []model.TableDiff) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PROFILE\tTABLE\tINSERTS\tUPDATES\tDELETES\tUNCHANGED")
+
+	var total model.TableDiff
+	for _, d := range diffs {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%d\n", d.Profile, d.Table, d.Inserts, d.Updates, d.Deletes, d.Unchanged)
+		total.Inserts += d.Inserts
+		total.Updates += d.Updates
+		total.Deletes += d.Deletes
+		total.Unchanged += d.Unchanged
+	}
+	fmt.Fprintf(tw, "TOTAL\t\t%d\t%d\t%d\t%d\n", total.Inserts, total.Updates, total.Deletes, total.Unchanged)
+	return tw.Flush()
+}
+
+// WriteReport сохраняет отчет в JSON файл path.
+func WriteReport(path string, report Report) error {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write dry-run report: %w", err)
+	}
+	return nil
+}
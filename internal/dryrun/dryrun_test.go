@@ -0,0 +1,70 @@
+package dryrun
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/nemirlev/zenexport/internal/db/model"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Тестируем подсчет вставок, обновлений и удалений
+func TestCompare(t *testing.T) {
+	incoming := map[string]map[string]string{
+		"tag":         {"a": "1", "b": "2", "c": "3"},
+		"transaction": {"x": "10"},
+	}
+	stored := map[string]map[string]string{
+		"tag":         {"a": "1", "b": "1", "d": "5"},
+		"transaction": {},
+	}
+
+	diffs := Compare("home", []string{"tag", "transaction"}, incoming, stored)
+
+	assert.Equal(t, []model.TableDiff{
+		{Table: "tag", Profile: "home", Inserts: 1, Updates: 1, Deletes: 1, Unchanged: 1},
+		{Table: "transaction", Profile: "home", Inserts: 1},
+	}, diffs)
+	assert.True(t, diffs[0].Changed())
+}
+
+// Тестируем, что таблица без данных с обеих сторон не считается измененной
+func TestCompareEmpty(t *testing.T) {
+	diffs := Compare("default", []string{"budget"}, nil, nil)
+
+	assert.Equal(t, []model.TableDiff{{Table: "budget", Profile: "default"}}, diffs)
+	assert.False(t, diffs[0].Changed())
+}
+
+// Тестируем итоговую строку сводки
+func TestPrint(t *testing.T) {
+	var buf bytes.Buffer
+	err := Print(&buf, []model.TableDiff{
+		{Table: "tag", Profile: "home", Inserts: 1, Updates: 2},
+		{Table: "account", Profile: "home", Deletes: 3, Unchanged: 4},
+	})
+
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "TOTAL")
+	assert.Regexp(t, `TOTAL\s+1\s+2\s+3\s+4`, buf.String())
+}
+
+// Тестируем запись JSON отчета
+func TestWriteReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.json")
+	report := Report{
+		GeneratedAt: time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC),
+		Tables:      []model.TableDiff{{Table: "tag", Profile: "home", Inserts: 1}},
+	}
+
+	assert.NoError(t, WriteReport(path, report))
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	var got Report
+	assert.NoError(t, json.Unmarshal(data, &got))
+	assert.Equal(t, report, got)
+}
@@ -0,0 +1,37 @@
+package entity
+
+import "sort"
+
+// Changes ключи строк, которые отличаются в двух наборах версий.
+type Changes struct {
+	// Added строки, которых не было.
+	Added []string
+	// Changed строки с другой версией.
+	Changed []string
+	// Removed строки, которых больше нет.
+	Removed []string
+}
+
+// Diff сравнивает версии строк stored с новыми версиями incoming. Наборы версий имеют тот же вид,
+// что и у Versions: ключ строки - ее версия. Ключи в результате отсортированы.
+func Diff(stored, incoming map[string]string) Changes {
+	var c Changes
+	for key, version := range incoming {
+		storedVersion, ok := stored[key]
+		switch {
+		case !ok:
+			c.Added = append(c.Added, key)
+		case storedVersion != version:
+			c.Changed = append(c.Changed, key)
+		}
+	}
+	for key := range stored {
+		if _, ok := incoming[key]; !ok {
+			c.Removed = append(c.Removed, key)
+		}
+	}
+	sort.Strings(c.Added)
+	sort.Strings(c.Changed)
+	sort.Strings(c.Removed)
+	return c
+}
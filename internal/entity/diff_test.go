@@ -0,0 +1,20 @@
+package entity
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// Тестируем поиск добавленных, измененных и удаленных строк
+func TestDiff(t *testing.T) {
+	stored := map[string]string{"a": "1", "b": "1", "c": "1"}
+	incoming := map[string]string{"a": "1", "b": "2", "e": "1", "d": "1"}
+
+	assert.Equal(t, Changes{
+		Added:   []string{"d", "e"},
+		Changed: []string{"b"},
+		Removed: []string{"c"},
+	}, Diff(stored, incoming))
+
+	assert.Equal(t, Changes{}, Diff(incoming, incoming))
+}
@@ -0,0 +1,158 @@
+// Package entity описывает сущности ДзенМани, которые экспортируются в базу данных.
+// Имена сущностей совпадают с именами таблиц и используются в фильтрах INCLUDE_ENTITIES и EXCLUDE_ENTITIES.
+package entity
+
+import (
+	"fmt"
+	"github.com/nemirlev/zenapi"
+	"github.com/nemirlev/zenexport/internal/entity/names"
+	"strconv"
+	"strings"
+)
+
+// Имена сущностей, они же имена таблиц.
+const (
+	Instrument     = names.Instrument
+	Country        = names.Country
+	Company        = names.Company
+	User           = names.User
+	Account        = names.Account
+	Tag            = names.Tag
+	Merchant       = names.Merchant
+	Budget         = names.Budget
+	Reminder       = names.Reminder
+	ReminderMarker = names.ReminderMarker
+	Transaction    = names.Transaction
+)
+
+// All содержит все сущности в порядке их зависимостей.
+var All = names.All
+
+// IsKnown проверяет, что сущность с таким именем существует.
+func IsKnown(name string) bool {
+	return names.IsKnown(name)
+}
+
+// Filter определяет, какие сущности нужно сохранять. Пустой список include означает все сущности,
+// сущности из exclude пропускаются всегда.
+type Filter struct {
+	include map[string]bool
+	exclude map[string]bool
+}
+
+// NewFilter создает фильтр по спискам включаемых и исключаемых сущностей.
+// Возвращает ошибку, если в списках есть неизвестные сущности.
+func NewFilter(include, exclude []string) (Filter, error) {
+	if unknown := append(names.Unknown(include), names.Unknown(exclude)...); len(unknown) > 0 {
+		return Filter{}, fmt.Errorf("unknown entities: %s", strings.Join(unknown, ", "))
+	}
+
+	f := Filter{
+		include: make(map[string]bool, len(include)),
+		exclude: make(map[string]bool, len(exclude)),
+	}
+	for _, name := range include {
+		f.include[name] = true
+	}
+	for _, name := range exclude {
+		f.exclude[name] = true
+	}
+	return f, nil
+}
+
+// Enabled возвращает true, если сущность должна быть сохранена.
+func (f Filter) Enabled(name string) bool {
+	if f.exclude[name] {
+		return false
+	}
+	return len(f.include) == 0 || f.include[name]
+}
+
+// Counts возвращает количество строк каждой сущности в ответе ZenMoney.
+func Counts(data *zenapi.Response) map[string]uint64 {
+	return map[string]uint64{
+		Instrument:     uint64(len(data.Instrument)),
+		Country:        uint64(len(data.Country)),
+		Company:        uint64(len(data.Company)),
+		User:           uint64(len(data.User)),
+		Account:        uint64(len(data.Account)),
+		Tag:            uint64(len(data.Tag)),
+		Merchant:       uint64(len(data.Merchant)),
+		Budget:         uint64(len(data.Budget)),
+		Reminder:       uint64(len(data.Reminder)),
+		ReminderMarker: uint64(len(data.ReminderMarker)),
+		Transaction:    uint64(len(data.Transaction)),
+	}
+}
+
+// Items возвращает срезы строк каждой сущности из ответа ZenMoney.
+func Items(data *zenapi.Response) map[string]any {
+	return map[string]any{
+		Instrument:     data.Instrument,
+		Country:        data.Country,
+		Company:        data.Company,
+		User:           data.User,
+		Account:        data.Account,
+		Tag:            data.Tag,
+		Merchant:       data.Merchant,
+		Budget:         data.Budget,
+		Reminder:       data.Reminder,
+		ReminderMarker: data.ReminderMarker,
+		Transaction:    data.Transaction,
+	}
+}
+
+// Versions возвращает версии строк каждой сущности из ответа ZenMoney по их ключам.
+// Версией служит поле changed, а у стран, где его нет, - значения всех полей.
+// Ключ бюджета составной: user/tag/date. Ключи и версии совпадают с теми, что возвращает DataStore.Versions.
+func Versions(data *zenapi.Response) map[string]map[string]string {
+	versions := make(map[string]map[string]string, len(All))
+	for _, name := range All {
+		versions[name] = map[string]string{}
+	}
+
+	for _, item := range data.Instrument {
+		versions[Instrument][strconv.Itoa(item.ID)] = strconv.Itoa(item.Changed)
+	}
+	for _, item := range data.Country {
+		versions[Country][strconv.Itoa(item.ID)] = fmt.Sprintf("%s|%d|%s", item.Title, item.Currency, item.Domain)
+	}
+	for _, item := range data.Company {
+		versions[Company][strconv.Itoa(item.ID)] = strconv.Itoa(item.Changed)
+	}
+	for _, item := range data.User {
+		versions[User][strconv.Itoa(item.ID)] = strconv.Itoa(item.Changed)
+	}
+	for _, item := range data.Account {
+		versions[Account][item.ID] = strconv.Itoa(item.Changed)
+	}
+	for _, item := range data.Tag {
+		versions[Tag][item.ID] = strconv.Itoa(item.Changed)
+	}
+	for _, item := range data.Merchant {
+		versions[Merchant][item.ID] = strconv.Itoa(item.Changed)
+	}
+	for _, item := range data.Budget {
+		versions[Budget][BudgetKey(item.User, item.Tag, item.Date)] = strconv.Itoa(item.Changed)
+	}
+	for _, item := range data.Reminder {
+		versions[Reminder][item.ID] = strconv.Itoa(item.Changed)
+	}
+	for _, item := range data.ReminderMarker {
+		versions[ReminderMarker][item.ID] = strconv.Itoa(item.Changed)
+	}
+	for _, item := range data.Transaction {
+		versions[Transaction][item.ID] = strconv.Itoa(item.Changed)
+	}
+	return versions
+}
+
+// BudgetKey возвращает ключ бюджета. У бюджета нет идентификатора, он однозначно определяется пользователем,
+// категорией и месяцем.
+func BudgetKey(user int, tag *string, date string) string {
+	t := ""
+	if tag != nil {
+		t = *tag
+	}
+	return fmt.Sprintf("%d/%s/%s", user, t, date)
+}
@@ -0,0 +1,20 @@
+package entity
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// Тестируем фильтр сущностей со списками включаемых и исключаемых сущностей
+func TestNewFilter(t *testing.T) {
+	filter, err := NewFilter([]string{"transaction", "account"}, []string{"account"})
+	assert.NoError(t, err)
+
+	// Включены только перечисленные сущности, кроме исключенных
+	assert.True(t, filter.Enabled(Transaction))
+	assert.False(t, filter.Enabled(Account))
+	assert.False(t, filter.Enabled(Country))
+
+	_, err = NewFilter([]string{"accounts"}, []string{"tags"})
+	assert.EqualError(t, err, "unknown entities: accounts, tags")
+}
@@ -0,0 +1,43 @@
+// Package names содержит имена сущностей ДзенМани без зависимости от клиента API, чтобы их можно было проверять
+// при разборе конфигурации.
+package names
+
+const (
+	Instrument     = "instrument"
+	Country        = "country"
+	Company        = "company"
+	User           = "user"
+	Account        = "account"
+	Tag            = "tag"
+	Merchant       = "merchant"
+	Budget         = "budget"
+	Reminder       = "reminder"
+	ReminderMarker = "reminder_marker"
+	Transaction    = "transaction"
+)
+
+// All содержит все сущности в порядке их зависимостей.
+var All = []string{
+	Instrument, Country, Company, User, Account, Tag, Merchant, Budget, Reminder, ReminderMarker, Transaction,
+}
+
+// IsKnown проверяет, что сущность с таким именем существует.
+func IsKnown(name string) bool {
+	for _, e := range All {
+		if e == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Unknown возвращает неизвестные сущности из списка.
+func Unknown(list []string) []string {
+	var unknown []string
+	for _, name := range list {
+		if !IsKnown(name) {
+			unknown = append(unknown, name)
+		}
+	}
+	return unknown
+}
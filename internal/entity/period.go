@@ -0,0 +1,140 @@
+package entity
+
+import (
+	"fmt"
+	"github.com/nemirlev/zenapi"
+	"time"
+)
+
+// DateLayout формат дат ZenMoney и параметров SINCE/UNTIL.
+const DateLayout = "2006-01-02"
+
+// Dated содержит сущности с датой, которые можно выгружать и перезаписывать за период.
+var Dated = []string{Budget, ReminderMarker, Transaction}
+
+// IsDated проверяет, что у сущности есть дата и ее можно ограничить периодом.
+func IsDated(name string) bool {
+	for _, e := range Dated {
+		if e == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Period диапазон дат [Since, Until], обе границы включаются. Нулевая граница означает, что период с этой
+// стороны не ограничен.
+type Period struct {
+	Since time.Time
+	Until time.Time
+}
+
+// ParsePeriod разбирает границы периода в формате DateLayout. Пустая строка означает неограниченную границу.
+func ParsePeriod(since, until string) (Period, error) {
+	var p Period
+	var err error
+	if since != "" {
+		if p.Since, err = time.Parse(DateLayout, since); err != nil {
+			return Period{}, fmt.Errorf("invalid since date %q, expected YYYY-MM-DD", since)
+		}
+	}
+	if until != "" {
+		if p.Until, err = time.Parse(DateLayout, until); err != nil {
+			return Period{}, fmt.Errorf("invalid until date %q, expected YYYY-MM-DD", until)
+		}
+	}
+	if p.Bounded() && p.Until.Before(p.Since) {
+		return Period{}, fmt.Errorf("since date %s is after until date %s", since, until)
+	}
+	return p, nil
+}
+
+// IsZero возвращает true, если период не ограничен ни с одной стороны.
+func (p Period) IsZero() bool {
+	return p.Since.IsZero() && p.Until.IsZero()
+}
+
+// Bounded возвращает true, если заданы обе границы периода.
+func (p Period) Bounded() bool {
+	return !p.Since.IsZero() && !p.Until.IsZero()
+}
+
+// Contains проверяет, что дата в формате DateLayout попадает в период.
+// Даты ZenMoney сравниваются как строки, поэтому разбирать их не нужно.
+func (p Period) Contains(date string) bool {
+	if !p.Since.IsZero() && date < p.Since.Format(DateLayout) {
+		return false
+	}
+	if !p.Until.IsZero() && date > p.Until.Format(DateLayout) {
+		return false
+	}
+	return true
+}
+
+// Year часть периода, попадающая в один календарный год. Таблицы сущностей с датой разбиты на партиции
+// по профилю и году: партиция на каждый месяц быстро превысила бы лимит ClickHouse
+// max_partitions_per_insert_block (100) при вставке истории за несколько лет.
+type Year struct {
+	// Partition номер года, как у toYear в ClickHouse.
+	Partition int
+	Since     time.Time
+	Until     time.Time
+	// Full true, если период покрывает год целиком.
+	Full bool
+}
+
+// Years разбивает ограниченный период на годы. Для неограниченного периода возвращает nil.
+func (p Period) Years() []Year {
+	if !p.Bounded() {
+		return nil
+	}
+
+	var years []Year
+	for year := p.Since.Year(); year <= p.Until.Year(); year++ {
+		start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+		end := time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC)
+		y := Year{
+			Partition: year,
+			Since:     start,
+			Until:     end,
+			Full:      !p.Since.After(start) && !p.Until.Before(end),
+		}
+		if p.Since.After(y.Since) {
+			y.Since = p.Since
+		}
+		if p.Until.Before(y.Until) {
+			y.Until = p.Until
+		}
+		years = append(years, y)
+	}
+	return years
+}
+
+// FilterPeriod возвращает копию данных, в которой бюджеты, отметки напоминаний и транзакции ограничены периодом.
+// Остальные сущности не меняются.
+func FilterPeriod(data *zenapi.Response, period Period) *zenapi.Response {
+	if period.IsZero() {
+		return data
+	}
+
+	filtered := *data
+	filtered.Budget = nil
+	for _, item := range data.Budget {
+		if period.Contains(item.Date) {
+			filtered.Budget = append(filtered.Budget, item)
+		}
+	}
+	filtered.ReminderMarker = nil
+	for _, item := range data.ReminderMarker {
+		if period.Contains(item.Date) {
+			filtered.ReminderMarker = append(filtered.ReminderMarker, item)
+		}
+	}
+	filtered.Transaction = nil
+	for _, item := range data.Transaction {
+		if period.Contains(item.Date) {
+			filtered.Transaction = append(filtered.Transaction, item)
+		}
+	}
+	return &filtered
+}
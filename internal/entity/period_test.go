@@ -0,0 +1,79 @@
+package entity
+
+import (
+	"github.com/nemirlev/zenapi"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, _ := time.Parse(DateLayout, s)
+	return t
+}
+
+// Тестируем разбор границ периода
+func TestParsePeriod(t *testing.T) {
+	p, err := ParsePeriod("2024-01-15", "2024-03-31")
+	assert.NoError(t, err)
+	assert.Equal(t, Period{Since: date("2024-01-15"), Until: date("2024-03-31")}, p)
+
+	p, err = ParsePeriod("", "")
+	assert.NoError(t, err)
+	assert.True(t, p.IsZero())
+
+	_, err = ParsePeriod("2024-13-01", "")
+	assert.EqualError(t, err, `invalid since date "2024-13-01", expected YYYY-MM-DD`)
+
+	_, err = ParsePeriod("2024-03-01", "2024-01-01")
+	assert.EqualError(t, err, "since date 2024-03-01 is after until date 2024-01-01")
+}
+
+// Тестируем проверку попадания даты в период, в том числе с одной границей
+func TestPeriodContains(t *testing.T) {
+	p := Period{Since: date("2024-01-15"), Until: date("2024-01-31")}
+	assert.True(t, p.Contains("2024-01-15"))
+	assert.True(t, p.Contains("2024-01-31"))
+	assert.False(t, p.Contains("2024-01-14"))
+	assert.False(t, p.Contains("2024-02-01"))
+
+	since := Period{Since: date("2024-01-15")}
+	assert.True(t, since.Contains("2030-01-01"))
+	assert.False(t, since.Contains("2024-01-01"))
+}
+
+// Тестируем разбиение периода на годы
+func TestPeriodYears(t *testing.T) {
+	p := Period{Since: date("2022-03-15"), Until: date("2024-06-30")}
+
+	assert.Equal(t, []Year{
+		{Partition: 2022, Since: date("2022-03-15"), Until: date("2022-12-31"), Full: false},
+		{Partition: 2023, Since: date("2023-01-01"), Until: date("2023-12-31"), Full: true},
+		{Partition: 2024, Since: date("2024-01-01"), Until: date("2024-06-30"), Full: false},
+	}, p.Years())
+	assert.Nil(t, Period{Since: date("2024-01-15")}.Years())
+
+	// Период за десять лет, больше 100 месяцев, укладывается в лимит партиций на одну вставку
+	p = Period{Since: date("2015-01-01"), Until: date("2024-12-31")}
+	assert.Len(t, p.Years(), 10)
+	for _, y := range p.Years() {
+		assert.True(t, y.Full)
+	}
+}
+
+// Тестируем, что период ограничивает только сущности с датой
+func TestFilterPeriod(t *testing.T) {
+	data := &zenapi.Response{
+		Transaction: []zenapi.Transaction{{ID: "a", Date: "2024-01-10"}, {ID: "b", Date: "2024-02-10"}},
+		Budget:      []zenapi.Budget{{Date: "2024-01-01"}, {Date: "2024-02-01"}},
+		Tag:         []zenapi.Tag{{ID: "t"}},
+	}
+
+	filtered := FilterPeriod(data, Period{Since: date("2024-02-01")})
+
+	assert.Equal(t, []zenapi.Transaction{{ID: "b", Date: "2024-02-10"}}, filtered.Transaction)
+	assert.Equal(t, []zenapi.Budget{{Date: "2024-02-01"}}, filtered.Budget)
+	assert.Empty(t, filtered.ReminderMarker)
+	assert.Equal(t, data.Tag, filtered.Tag)
+	assert.Len(t, data.Transaction, 2)
+}
@@ -0,0 +1,149 @@
+// Package export выгружает данные ZenMoney в файлы без записи в базу данных.
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/nemirlev/zenapi"
+	"github.com/nemirlev/zenexport/internal/entity"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// Поддерживаемые форматы выгрузки.
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+)
+
+// Formats содержит все поддерживаемые форматы.
+var Formats = []string{FormatJSON, FormatCSV}
+
+// Write выгружает данные профиля в каталог dir.
+// В формате json создается файл <profile>.json с сущностями, включенными фильтром.
+// В формате csv создается каталог <profile> с файлом <сущность>.csv для каждой включенной сущности.
+func Write(dir string, profile string, format string, data *zenapi.Response, filter entity.Filter) error {
+	items := entity.Items(data)
+	for name := range items {
+		if !filter.Enabled(name) {
+			delete(items, name)
+		}
+	}
+
+	switch format {
+	case FormatJSON:
+		return writeJSON(filepath.Join(dir, profile+".json"), items)
+	case FormatCSV:
+		profileDir := filepath.Join(dir, profile)
+		if err := os.MkdirAll(profileDir, 0o755); err != nil {
+			return err
+		}
+		for name, rows := range items {
+			if err := writeCSV(filepath.Join(profileDir, name+".csv"), rows); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported export format %q, supported: %s", format, strings.Join(Formats, ", "))
+	}
+}
+
+// writeJSON записывает сущности в один JSON-документ.
+func writeJSON(path string, items map[string]any) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(items); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// writeCSV записывает срез структур в CSV. Заголовки - имена полей в snake_case,
+// nil-значения записываются пустыми строками, а массивы - через точку с запятой.
+func writeCSV(path string, rows any) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	slice := reflect.ValueOf(rows)
+	itemType := slice.Type().Elem()
+
+	header := make([]string, itemType.NumField())
+	for i := range header {
+		header[i] = snakeCase(itemType.Field(i).Name)
+	}
+	if err := w.Write(header); err != nil {
+		return err
+	}
+
+	record := make([]string, len(header))
+	for i := 0; i < slice.Len(); i++ {
+		item := slice.Index(i)
+		for j := range record {
+			record[j] = formatValue(item.Field(j))
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// formatValue преобразует значение поля в строку для CSV.
+func formatValue(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return ""
+		}
+		return formatValue(v.Elem())
+	case reflect.Slice:
+		parts := make([]string, v.Len())
+		for i := range parts {
+			parts[i] = formatValue(v.Index(i))
+		}
+		return strings.Join(parts, ";")
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// snakeCase преобразует имя поля Go в snake_case: OutcomeInstrument -> outcome_instrument, SyncID -> sync_id.
+func snakeCase(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			prevLower := i > 0 && unicode.IsLower(runes[i-1])
+			nextLower := i > 0 && i+1 < len(runes) && unicode.IsUpper(runes[i-1]) && unicode.IsLower(runes[i+1])
+			if prevLower || nextLower {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
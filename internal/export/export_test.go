@@ -0,0 +1,83 @@
+package export
+
+import (
+	"encoding/json"
+	"github.com/nemirlev/zenapi"
+	"github.com/nemirlev/zenexport/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// Тестируем преобразование имен полей в заголовки CSV
+func TestSnakeCase(t *testing.T) {
+	tests := map[string]string{
+		"ID":                "id",
+		"Title":             "title",
+		"OutcomeInstrument": "outcome_instrument",
+		"SyncID":            "sync_id",
+		"IDName":            "id_name",
+	}
+	for name, want := range tests {
+		assert.Equal(t, want, snakeCase(name), name)
+	}
+}
+
+// Тестируем преобразование значений полей в строки CSV
+func TestFormatValue(t *testing.T) {
+	balance := 10.5
+	var empty *float64
+
+	assert.Equal(t, "10.5", formatValue(reflect.ValueOf(&balance)))
+	assert.Equal(t, "", formatValue(reflect.ValueOf(empty)))
+	assert.Equal(t, "a;b", formatValue(reflect.ValueOf([]string{"a", "b"})))
+	assert.Equal(t, "", formatValue(reflect.ValueOf([]string(nil))))
+	assert.Equal(t, "true", formatValue(reflect.ValueOf(true)))
+}
+
+// Тестируем запись среза структур в CSV
+func TestWriteCSV(t *testing.T) {
+	type row struct {
+		ID      string
+		SyncID  []string
+		Balance *float64
+	}
+	balance := 1.5
+	path := filepath.Join(t.TempDir(), "account.csv")
+
+	assert.NoError(t, writeCSV(path, []row{
+		{ID: "a", SyncID: []string{"1", "2"}, Balance: &balance},
+		{ID: "b"},
+	}))
+
+	content, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "id,sync_id,balance\na,1;2,1.5\nb,,\n", string(content))
+}
+
+// Тестируем выгрузку профиля в JSON и CSV только с включенными сущностями
+func TestWrite(t *testing.T) {
+	data := &zenapi.Response{
+		Account: []zenapi.Account{{ID: "card"}},
+		Tag:     []zenapi.Tag{{ID: "food"}},
+	}
+	filter, err := entity.NewFilter(nil, []string{entity.Tag})
+	assert.NoError(t, err)
+	dir := t.TempDir()
+
+	assert.NoError(t, Write(dir, "alice", FormatJSON, data, filter))
+	content, err := os.ReadFile(filepath.Join(dir, "alice.json"))
+	assert.NoError(t, err)
+	var items map[string]json.RawMessage
+	assert.NoError(t, json.Unmarshal(content, &items))
+	assert.Contains(t, items, entity.Account)
+	assert.NotContains(t, items, entity.Tag)
+
+	assert.NoError(t, Write(dir, "alice", FormatCSV, data, filter))
+	assert.FileExists(t, filepath.Join(dir, "alice", entity.Account+".csv"))
+	assert.NoFileExists(t, filepath.Join(dir, "alice", entity.Tag+".csv"))
+
+	assert.Error(t, Write(dir, "alice", "xml", data, filter))
+}
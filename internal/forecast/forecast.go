@@ -0,0 +1,130 @@
+// Package forecast разворачивает напоминания ДзенМани в плановые операции.
+// Напоминание повторяется каждые step интервалов interval (day, week, month, year) начиная со start_date и до
+// end_date включительно. Внутри каждого шага операции приходятся на смещения points в тех же интервалах. Напоминание
+// без интервала - разовая операция в start_date.
+package forecast
+
+import (
+	"github.com/nemirlev/zenapi"
+	"sort"
+	"time"
+)
+
+// Occurrence плановая операция напоминания на дату Date. Если на эту дату уже есть отметка напоминания,
+// Marker и State содержат ее идентификатор и состояние.
+type Occurrence struct {
+	Reminder string
+	Date     time.Time
+	Marker   *string
+	State    *string
+}
+
+// Expand возвращает плановые операции напоминаний с from по until включительно, отсортированные по дате
+// и напоминанию. Отметки напоминаний сопоставляются с операциями по напоминанию и дате.
+func Expand(reminders []zenapi.Reminder, markers []zenapi.ReminderMarker, from, until time.Time) []Occurrence {
+	from, until = day(from), day(until)
+
+	type key struct {
+		reminder string
+		date     string
+	}
+	marked := make(map[key]zenapi.ReminderMarker, len(markers))
+	for _, m := range markers {
+		marked[key{m.Reminder, m.Date}] = m
+	}
+
+	var result []Occurrence
+	for _, r := range reminders {
+		for _, date := range Dates(r, from, until) {
+			o := Occurrence{Reminder: r.ID, Date: date}
+			if m, ok := marked[key{r.ID, date.Format(time.DateOnly)}]; ok {
+				o.Marker, o.State = &m.ID, &m.State
+			}
+			result = append(result, o)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if !result[i].Date.Equal(result[j].Date) {
+			return result[i].Date.Before(result[j].Date)
+		}
+		return result[i].Reminder < result[j].Reminder
+	})
+	return result
+}
+
+// Dates возвращает даты операций напоминания с from по until включительно. Напоминание с неразобранными
+// датами или неизвестным интервалом дат не дает.
+func Dates(r zenapi.Reminder, from, until time.Time) []time.Time {
+	start, err := time.Parse(time.DateOnly, r.StartDate)
+	if err != nil {
+		return nil
+	}
+	if r.EndDate != nil && *r.EndDate != "" {
+		end, err := time.Parse(time.DateOnly, *r.EndDate)
+		if err != nil {
+			return nil
+		}
+		if end.Before(until) {
+			until = end
+		}
+	}
+
+	if r.Interval == nil {
+		if start.Before(from) || start.After(until) {
+			return nil
+		}
+		return []time.Time{start}
+	}
+	if _, ok := units[*r.Interval]; !ok {
+		return nil
+	}
+
+	step := 1
+	if r.Step != nil && *r.Step > 0 {
+		step = *r.Step
+	}
+	points := r.Points
+	if len(points) == 0 {
+		points = []int{0}
+	}
+
+	var dates []time.Time
+	for n := 0; ; n += step {
+		if add(start, *r.Interval, n).After(until) {
+			break
+		}
+		for _, point := range points {
+			date := add(start, *r.Interval, n+point)
+			if !date.Before(from) && !date.After(until) {
+				dates = append(dates, date)
+			}
+		}
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+	return dates
+}
+
+// units длительность интервала напоминания в днях или месяцах.
+var units = map[string]struct{ days, months int }{
+	"day":   {days: 1},
+	"week":  {days: 7},
+	"month": {months: 1},
+	"year":  {months: 12},
+}
+
+// add прибавляет к start n интервалов. Если в целевом месяце нет дня start, берется последний день месяца.
+func add(start time.Time, interval string, n int) time.Time {
+	u := units[interval]
+	if u.months == 0 {
+		return start.AddDate(0, 0, n*u.days)
+	}
+	month := time.Date(start.Year(), start.Month()+time.Month(n*u.months), 1, 0, 0, 0, 0, time.UTC)
+	last := month.AddDate(0, 1, -1).Day()
+	return month.AddDate(0, 0, min(start.Day(), last)-1)
+}
+
+// day возвращает начало дня t в UTC, так же как разбираются даты напоминаний.
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,69 @@
+package forecast
+
+import (
+	"github.com/nemirlev/zenapi"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, _ := time.Parse(time.DateOnly, s)
+	return t
+}
+
+func ref[T any](v T) *T {
+	return &v
+}
+
+func dates(values ...string) []time.Time {
+	result := make([]time.Time, len(values))
+	for i, v := range values {
+		result[i] = date(v)
+	}
+	return result
+}
+
+// Тестируем даты напоминаний с разными интервалами
+func TestDates(t *testing.T) {
+	from, until := date("2024-01-01"), date("2024-04-30")
+
+	// Разовое напоминание
+	assert.Equal(t, dates("2024-02-10"), Dates(zenapi.Reminder{StartDate: "2024-02-10"}, from, until))
+	assert.Empty(t, Dates(zenapi.Reminder{StartDate: "2023-12-31"}, from, until))
+
+	// Каждый месяц 31 числа, в коротких месяцах - последний день
+	monthly := zenapi.Reminder{StartDate: "2023-12-31", Interval: ref("month"), Step: ref(1), Points: []int{0}}
+	assert.Equal(t, dates("2024-01-31", "2024-02-29", "2024-03-31", "2024-04-30"), Dates(monthly, from, until))
+
+	// Первый и третий день каждой недели до end_date
+	weekly := zenapi.Reminder{StartDate: "2024-04-01", EndDate: ref("2024-04-10"), Interval: ref("day"), Step: ref(7), Points: []int{0, 2}}
+	assert.Equal(t, dates("2024-04-01", "2024-04-03", "2024-04-08", "2024-04-10"), Dates(weekly, from, until))
+
+	// Раз в квартал
+	quarterly := zenapi.Reminder{StartDate: "2023-11-15", Interval: ref("month"), Step: ref(3)}
+	assert.Equal(t, dates("2024-02-15"), Dates(quarterly, from, until))
+
+	assert.Empty(t, Dates(zenapi.Reminder{StartDate: "2024-01-01", Interval: ref("fortnight")}, from, until))
+	assert.Empty(t, Dates(zenapi.Reminder{StartDate: "bad"}, from, until))
+}
+
+// Тестируем плановые операции с отметками напоминаний
+func TestExpand(t *testing.T) {
+	reminders := []zenapi.Reminder{
+		{ID: "rent", StartDate: "2024-01-05", Interval: ref("month"), Step: ref(1), Points: []int{0}},
+		{ID: "gift", StartDate: "2024-02-01"},
+	}
+	markers := []zenapi.ReminderMarker{
+		{ID: "m1", Reminder: "rent", Date: "2024-01-05", State: "processed"},
+		{ID: "m2", Reminder: "gift", Date: "2024-02-02", State: "planned"},
+	}
+
+	result := Expand(reminders, markers, time.Date(2024, 1, 1, 15, 0, 0, 0, time.UTC), date("2024-02-29"))
+
+	assert.Equal(t, []Occurrence{
+		{Reminder: "rent", Date: date("2024-01-05"), Marker: ref("m1"), State: ref("processed")},
+		{Reminder: "gift", Date: date("2024-02-01")},
+		{Reminder: "rent", Date: date("2024-02-05")},
+	}, result)
+}
@@ -0,0 +1,113 @@
+package ledger
+
+import (
+	"github.com/nemirlev/zenapi"
+	"sort"
+	"time"
+)
+
+// DailyBalance остаток счета на конец дня и обороты за день.
+type DailyBalance struct {
+	Account    string
+	User       int
+	Instrument *int
+	Date       time.Time
+	Income     float64
+	Outcome    float64
+	Balance    float64
+}
+
+// Balances возвращает остатки счетов, вычисленные как start_balance плюс все движения по счету.
+// Движения по счетам, которых нет в accounts, не учитываются.
+func Balances(accounts []zenapi.Account, transactions []zenapi.Transaction) map[string]float64 {
+	balances := make(map[string]float64, len(accounts))
+	for _, account := range accounts {
+		balances[account.ID] = startBalance(account)
+	}
+	for _, t := range transactions {
+		for _, leg := range Legs(t) {
+			if _, ok := balances[leg.Account]; ok {
+				balances[leg.Account] += leg.Amount
+			}
+		}
+	}
+	return balances
+}
+
+// Daily строит ежедневные остатки счетов по дату until включительно. Ряд счета начинается с более ранней из дат
+// первого движения и start_date счета и содержит каждый день, в том числе дни без движений. Если у счета нет ни
+// движений, ни даты открытия, возвращается один день until. Движения после until не учитываются.
+// Результат отсортирован по счету и дате.
+func Daily(accounts []zenapi.Account, transactions []zenapi.Transaction, until time.Time) []DailyBalance {
+	until = day(until)
+
+	// Обороты счетов по дням
+	type turnover struct{ income, outcome float64 }
+	turnovers := make(map[string]map[time.Time]turnover, len(accounts))
+	for _, account := range accounts {
+		turnovers[account.ID] = map[time.Time]turnover{}
+	}
+	for _, t := range transactions {
+		for _, leg := range Legs(t) {
+			days, ok := turnovers[leg.Account]
+			if !ok || leg.Date.After(until) {
+				continue
+			}
+			d := days[leg.Date]
+			if leg.Amount > 0 {
+				d.income += leg.Amount
+			} else {
+				d.outcome -= leg.Amount
+			}
+			days[leg.Date] = d
+		}
+	}
+
+	sorted := append([]zenapi.Account{}, accounts...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	var result []DailyBalance
+	for _, account := range sorted {
+		days := turnovers[account.ID]
+
+		start := until
+		if account.StartDate != nil {
+			if date, err := time.Parse(time.DateOnly, *account.StartDate); err == nil && date.Before(start) {
+				start = date
+			}
+		}
+		for date := range days {
+			if date.Before(start) {
+				start = date
+			}
+		}
+
+		balance := startBalance(account)
+		for date := start; !date.After(until); date = date.AddDate(0, 0, 1) {
+			d := days[date]
+			balance += d.income - d.outcome
+			result = append(result, DailyBalance{
+				Account:    account.ID,
+				User:       account.User,
+				Instrument: account.Instrument,
+				Date:       date,
+				Income:     d.income,
+				Outcome:    d.outcome,
+				Balance:    balance,
+			})
+		}
+	}
+	return result
+}
+
+func startBalance(account zenapi.Account) float64 {
+	if account.StartBalance == nil {
+		return 0
+	}
+	return *account.StartBalance
+}
+
+// day возвращает начало дня t в UTC, так же как разбираются даты транзакций.
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,106 @@
+package ledger
+
+import (
+	"github.com/nemirlev/zenapi"
+	"sort"
+)
+
+// Kind вид транзакции.
+type Kind string
+
+const (
+	// KindIncome доход: зачисление на счет без списания.
+	KindIncome Kind = "income"
+	// KindExpense расход: списание со счета без зачисления.
+	KindExpense Kind = "expense"
+	// KindTransfer перевод между двумя разными счетами.
+	KindTransfer Kind = "transfer"
+	// KindDebt операция с долговым счетом: выдача или возврат долга.
+	KindDebt Kind = "debt"
+	// KindCorrection списание и зачисление на одном и том же счете.
+	KindCorrection Kind = "correction"
+)
+
+// debtAccount тип счета ZenMoney, на котором учитываются долги.
+const debtAccount = "debt"
+
+// Entry движение по счету вместе с видом транзакции и ее аналитикой.
+type Entry struct {
+	Leg
+	User int
+	Kind Kind
+	// Tag первая категория транзакции.
+	Tag      *string
+	Merchant *string
+	Payee    string
+}
+
+// Classify определяет вид транзакции. debts содержит идентификаторы долговых счетов: операция, в которой участвует
+// такой счет, считается долгом, даже если по счетам это перевод.
+func Classify(t zenapi.Transaction, debts map[string]bool) Kind {
+	if debts[t.IncomeAccount] || debts[t.OutcomeAccount] {
+		return KindDebt
+	}
+	switch {
+	case t.IncomeAccount != t.OutcomeAccount:
+		return KindTransfer
+	case t.Income != 0 && t.Outcome != 0:
+		return KindCorrection
+	case t.Income != 0:
+		return KindIncome
+	default:
+		return KindExpense
+	}
+}
+
+// Debts возвращает идентификаторы долговых счетов для Classify.
+func Debts(accounts []zenapi.Account) map[string]bool {
+	debts := map[string]bool{}
+	for _, account := range accounts {
+		if account.Type == debtAccount {
+			debts[account.ID] = true
+		}
+	}
+	return debts
+}
+
+// Entries раскладывает транзакции на движения по счетам, помечая каждое видом транзакции.
+// Результат отсортирован по дате, транзакции и сумме, так что списание перевода идет перед зачислением.
+func Entries(accounts []zenapi.Account, transactions []zenapi.Transaction) []Entry {
+	debts := Debts(accounts)
+
+	var entries []Entry
+	for _, t := range transactions {
+		legs := Legs(t)
+		if len(legs) == 0 {
+			continue
+		}
+		kind := Classify(t, debts)
+		var tag *string
+		if len(t.Tag) > 0 {
+			tag = &t.Tag[0]
+		}
+		for _, leg := range legs {
+			entries = append(entries, Entry{
+				Leg:      leg,
+				User:     t.User,
+				Kind:     kind,
+				Tag:      tag,
+				Merchant: t.Merchant,
+				Payee:    t.Payee,
+			})
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if !a.Date.Equal(b.Date) {
+			return a.Date.Before(b.Date)
+		}
+		if a.Transaction != b.Transaction {
+			return a.Transaction < b.Transaction
+		}
+		return a.Amount < b.Amount
+	})
+	return entries
+}
@@ -0,0 +1,52 @@
+// Package ledger раскладывает транзакции ДзенМани на движения по счетам, определяет вид транзакций и строит
+// остатки счетов.
+// Транзакция списывает outcome со счета outcome_account и зачисляет income на счет income_account. У дохода и
+// расхода оба счета совпадают, а одна из сумм равна нулю, у перевода счета разные.
+package ledger
+
+import (
+	"github.com/nemirlev/zenapi"
+	"time"
+)
+
+// Leg движение по одному счету: положительная сумма - зачисление, отрицательная - списание.
+type Leg struct {
+	Transaction string
+	Account     string
+	Instrument  int
+	Date        time.Time
+	Amount      float64
+}
+
+// Legs возвращает движения по счетам транзакции. Удаленные транзакции, транзакции с неразобранной датой
+// и нулевые суммы движений не дают.
+func Legs(t zenapi.Transaction) []Leg {
+	if t.Deleted {
+		return nil
+	}
+	date, err := time.Parse(time.DateOnly, t.Date)
+	if err != nil {
+		return nil
+	}
+
+	var legs []Leg
+	if t.Outcome != 0 && t.OutcomeAccount != "" {
+		legs = append(legs, Leg{
+			Transaction: t.ID,
+			Account:     t.OutcomeAccount,
+			Instrument:  t.OutcomeInstrument,
+			Date:        date,
+			Amount:      -t.Outcome,
+		})
+	}
+	if t.Income != 0 && t.IncomeAccount != "" {
+		legs = append(legs, Leg{
+			Transaction: t.ID,
+			Account:     t.IncomeAccount,
+			Instrument:  t.IncomeInstrument,
+			Date:        date,
+			Amount:      t.Income,
+		})
+	}
+	return legs
+}
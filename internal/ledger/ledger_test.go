@@ -0,0 +1,121 @@
+package ledger
+
+import (
+	"github.com/nemirlev/zenapi"
+	"github.com/nemirlev/zenexport/internal/db/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, _ := time.Parse(time.DateOnly, s)
+	return t
+}
+
+func float(v float64) *float64 {
+	return &v
+}
+
+var (
+	accounts = []zenapi.Account{
+		{ID: "card", User: 1, StartBalance: float(100)},
+		{ID: "cash", User: 1},
+	}
+	transactions = []zenapi.Transaction{
+		// Расход с карты
+		{ID: "t1", Date: "2024-01-01", OutcomeAccount: "card", IncomeAccount: "card", Outcome: 30},
+		// Перевод с карты в наличные
+		{ID: "t2", Date: "2024-01-03", OutcomeAccount: "card", IncomeAccount: "cash", Outcome: 50, Income: 50},
+		// Удаленная транзакция не учитывается
+		{ID: "t3", Date: "2024-01-02", OutcomeAccount: "card", IncomeAccount: "card", Outcome: 1000, Deleted: true},
+		// Доход на счет, которого нет
+		{ID: "t4", Date: "2024-01-02", OutcomeAccount: "gone", IncomeAccount: "gone", Income: 5},
+	}
+)
+
+// Тестируем разбиение перевода на два движения
+func TestLegs(t *testing.T) {
+	legs := Legs(transactions[1])
+	assert.Equal(t, []Leg{
+		{Transaction: "t2", Account: "card", Date: date("2024-01-03"), Amount: -50},
+		{Transaction: "t2", Account: "cash", Date: date("2024-01-03"), Amount: 50},
+	}, legs)
+
+	assert.Empty(t, Legs(transactions[2]))
+	assert.Empty(t, Legs(zenapi.Transaction{Date: "bad", IncomeAccount: "card", Income: 1}))
+}
+
+// Тестируем остатки счетов
+func TestBalances(t *testing.T) {
+	assert.Equal(t, map[string]float64{"card": 20, "cash": 50}, Balances(accounts, transactions))
+}
+
+// Тестируем ежедневные остатки с днями без движений
+func TestDaily(t *testing.T) {
+	daily := Daily(accounts, transactions, date("2024-01-04"))
+
+	var card, cash []float64
+	for _, d := range daily {
+		switch d.Account {
+		case "card":
+			card = append(card, d.Balance)
+		case "cash":
+			cash = append(cash, d.Balance)
+		}
+	}
+	assert.Equal(t, []float64{70, 70, 20, 20}, card)
+	assert.Equal(t, []float64{50, 50}, cash)
+
+	assert.Equal(t, DailyBalance{Account: "card", User: 1, Date: date("2024-01-03"), Outcome: 50, Balance: 20}, daily[2])
+	assert.Equal(t, date("2024-01-03"), daily[4].Date)
+}
+
+// Тестируем сверку остатков с допуском
+func TestReconcile(t *testing.T) {
+	withBalances := []zenapi.Account{
+		{ID: "card", Title: "Card", StartBalance: float(100), Balance: float(20.004)},
+		{ID: "cash", Title: "Cash", Balance: float(45)},
+		{ID: "empty"},
+	}
+
+	results := Reconcile(withBalances, transactions, 0.01)
+
+	assert.Len(t, results, 2)
+	assert.Equal(t, model.ReconciliationOK, results[0].Status)
+	assert.Equal(t, "cash", results[1].Account)
+	assert.Equal(t, 50.0, results[1].Computed)
+	assert.Equal(t, 5.0, results[1].Difference)
+	assert.Equal(t, model.ReconciliationMismatch, results[1].Status)
+}
+
+// Тестируем определение вида транзакции
+func TestClassify(t *testing.T) {
+	debts := map[string]bool{"loan": true}
+
+	assert.Equal(t, KindExpense, Classify(transactions[0], debts))
+	assert.Equal(t, KindTransfer, Classify(transactions[1], debts))
+	assert.Equal(t, KindIncome, Classify(transactions[3], debts))
+	assert.Equal(t, KindDebt, Classify(zenapi.Transaction{OutcomeAccount: "card", IncomeAccount: "loan", Outcome: 10, Income: 10}, debts))
+	assert.Equal(t, KindCorrection, Classify(zenapi.Transaction{OutcomeAccount: "card", IncomeAccount: "card", Outcome: 10, Income: 3}, debts))
+}
+
+// Тестируем плоский журнал движений
+func TestEntries(t *testing.T) {
+	tag := "food"
+	withTag := append([]zenapi.Transaction{}, transactions...)
+	withTag[0].Tag = []string{tag, "other"}
+
+	entries := Entries(accounts, withTag)
+
+	assert.Len(t, entries, 4)
+	assert.Equal(t, Entry{
+		Leg:  Leg{Transaction: "t1", Account: "card", Date: date("2024-01-01"), Amount: -30},
+		Kind: KindExpense,
+		Tag:  &tag,
+	}, entries[0])
+	assert.Equal(t, "t4", entries[1].Transaction)
+	assert.Equal(t, KindIncome, entries[1].Kind)
+	assert.Equal(t, []float64{-50, 50}, []float64{entries[2].Amount, entries[3].Amount})
+	assert.Equal(t, KindTransfer, entries[3].Kind)
+}
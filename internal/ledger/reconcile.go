@@ -0,0 +1,38 @@
+package ledger
+
+import (
+	"github.com/nemirlev/zenapi"
+	"github.com/nemirlev/zenexport/internal/db/model"
+	"math"
+	"sort"
+)
+
+// Reconcile сверяет остаток каждого счета в ZenMoney с остатком, вычисленным по транзакциям и start_balance.
+// Счет считается расходящимся, если разница по модулю больше tolerance. Счета без остатка пропускаются.
+// Результат отсортирован по счету.
+func Reconcile(accounts []zenapi.Account, transactions []zenapi.Transaction, tolerance float64) []model.Reconciliation {
+	computed := Balances(accounts, transactions)
+
+	var results []model.Reconciliation
+	for _, account := range accounts {
+		if account.Balance == nil {
+			continue
+		}
+		r := model.Reconciliation{
+			Account:    account.ID,
+			Title:      account.Title,
+			Instrument: account.Instrument,
+			Balance:    *account.Balance,
+			Computed:   computed[account.ID],
+			Tolerance:  tolerance,
+			Status:     model.ReconciliationOK,
+		}
+		r.Difference = r.Computed - r.Balance
+		if math.Abs(r.Difference) > tolerance {
+			r.Status = model.ReconciliationMismatch
+		}
+		results = append(results, r)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Account < results[j].Account })
+	return results
+}
@@ -0,0 +1,58 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+)
+
+type contextKey struct{}
+
+// ContextWith возвращает контекст, в котором сохранены поля args. Они добавляются ко всем сообщениям,
+// записанным с этим контекстом, например run_id запуска синхронизации в логах хранилища.
+func ContextWith(ctx context.Context, args ...any) context.Context {
+	attrs := append(attrsFromContext(ctx), argsToAttrs(args)...)
+	return context.WithValue(ctx, contextKey{}, attrs)
+}
+
+func attrsFromContext(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+	attrs, _ := ctx.Value(contextKey{}).([]slog.Attr)
+	// Копия, чтобы дочерние контексты не меняли срез родителя
+	return append([]slog.Attr(nil), attrs...)
+}
+
+// argsToAttrs преобразует пары ключ-значение в атрибуты так же, как slog.Logger.
+func argsToAttrs(args []any) []slog.Attr {
+	var r slog.Record
+	r.Add(args...)
+
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	return attrs
+}
+
+// contextHandler добавляет к сообщению поля, сохраненные в контексте через ContextWith.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if attrs := attrsFromContext(ctx); len(attrs) > 0 {
+		r = r.Clone()
+		r.AddAttrs(attrs...)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
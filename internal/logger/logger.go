@@ -2,17 +2,134 @@ package logger
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"strings"
+	"time"
 )
 
+// Имена полей, общие для всех сообщений о синхронизации.
+const (
+	RunID    = "run_id"
+	Profile  = "profile"
+	Table    = "table"
+	Rows     = "rows"
+	Duration = "duration"
+)
+
+// Форматы логов.
+const (
+	FormatJSON   = "json"
+	FormatText   = "text"
+	FormatPretty = "pretty"
+)
+
+// Formats содержит поддерживаемые форматы логов.
+var Formats = []string{FormatJSON, FormatText, FormatPretty}
+
+// Levels содержит поддерживаемые уровни логирования.
+var Levels = []string{"debug", "info", "warn", "error"}
+
 type Log struct {
 	original *slog.Logger
+	level    *slog.LevelVar
+	closer   io.Closer
+}
+
+// Options настройки логгера.
+type Options struct {
+	// Level уровень логирования: debug, info, warn, error.
+	Level string
+	// Format формат сообщений: json, text или pretty.
+	Format string
+	// File путь к файлу логов. Если не задан, сообщения выводятся в stdout.
+	File string
 }
 
+// New создает логгер, который пишет JSON в stdout с уровнем info.
 func New() Log {
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
-	return Log{logger}
+	level := new(slog.LevelVar)
+	return Log{original: slog.New(newHandler(os.Stdout, FormatJSON, level)), level: level}
+}
+
+// NewWithOptions создает логгер с заданными уровнем, форматом и назначением.
+// Если задан файл, его нужно закрыть через Close.
+func NewWithOptions(opts Options) (Log, error) {
+	level := new(slog.LevelVar)
+	if opts.Level != "" {
+		if err := level.UnmarshalText([]byte(opts.Level)); err != nil {
+			return Log{}, fmt.Errorf("invalid log level %q: %w", opts.Level, err)
+		}
+	}
+
+	var w io.Writer = os.Stdout
+	var closer io.Closer
+	if opts.File != "" {
+		f, err := os.OpenFile(opts.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return Log{}, fmt.Errorf("failed to open log file: %w", err)
+		}
+		w, closer = f, f
+	}
+
+	format := opts.Format
+	if format == "" {
+		format = FormatJSON
+	}
+	return Log{original: slog.New(newHandler(w, format, level)), level: level, closer: closer}, nil
+}
+
+// newHandler создает обработчик в формате format, который дополняет сообщения полями из контекста.
+func newHandler(w io.Writer, format string, level *slog.LevelVar) slog.Handler {
+	opts := &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redactSecrets,
+	}
+
+	var h slog.Handler
+	switch format {
+	case FormatText:
+		h = slog.NewTextHandler(w, opts)
+	case FormatPretty:
+		h = newPrettyHandler(w, opts)
+	default:
+		h = slog.NewJSONHandler(w, opts)
+	}
+	return contextHandler{h}
+}
+
+// redactSecrets скрывает значения атрибутов, которые по имени похожи на секреты, например token или password.
+// Длительности выводятся в читаемом виде, например 1.5s.
+func redactSecrets(groups []string, a slog.Attr) slog.Attr {
+	key := strings.ToLower(a.Key)
+	if (strings.Contains(key, "token") || strings.Contains(key, "password")) &&
+		a.Value.Kind() == slog.KindString && a.Value.String() != "" {
+		return slog.String(a.Key, "[REDACTED]")
+	}
+	if a.Value.Kind() == slog.KindDuration {
+		return slog.String(a.Key, a.Value.Duration().Round(time.Millisecond).String())
+	}
+	return a
+}
+
+// SetLevel меняет уровень логирования. Допустимые значения: debug, info, warn, error.
+func (l *Log) SetLevel(level string) error {
+	return l.level.UnmarshalText([]byte(level))
+}
+
+// With возвращает логгер, который добавляет args к каждому сообщению.
+func (l *Log) With(args ...any) Log {
+	return Log{original: l.original.With(args...), level: l.level, closer: l.closer}
+}
+
+// Close закрывает файл логов, если он был открыт.
+func (l *Log) Close() error {
+	if l.closer == nil {
+		return nil
+	}
+	return l.closer.Close()
 }
 
 func (l *Log) Error(msg string, args ...any) {
@@ -20,19 +137,29 @@ func (l *Log) Error(msg string, args ...any) {
 }
 
 func (l *Log) WithError(err error, msg string, args ...any) {
+	l.WithErrorContext(context.Background(), err, msg, args...)
+}
+
+// WithErrorContext записывает ошибку вместе с полями из контекста ctx.
+func (l *Log) WithErrorContext(ctx context.Context, err error, msg string, args ...any) {
 	passArgs := make([]any, len(args)+2)
 	passArgs[0] = "error"
 	passArgs[1] = err
 	for i, arg := range args {
 		passArgs[i+2] = arg
 	}
-	l.original.Error(msg, passArgs...)
+	l.original.ErrorContext(ctx, msg, passArgs...)
 }
 
 func (l *Log) Info(msg string, args ...any) {
 	l.original.Info(msg, args...)
 }
 
+// InfoContext записывает сообщение вместе с полями из контекста ctx.
+func (l *Log) InfoContext(ctx context.Context, msg string, args ...any) {
+	l.original.InfoContext(ctx, msg, args...)
+}
+
 func (l *Log) Debug(msg string, args ...any) {
 	l.original.Debug(msg, args...)
 }
@@ -0,0 +1,73 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newFileLog создает логгер, который пишет во временный файл, и возвращает функцию чтения файла
+func newFileLog(t *testing.T, level, format string) (Log, func() string) {
+	path := filepath.Join(t.TempDir(), "zenexport.log")
+	log, err := NewWithOptions(Options{Level: level, Format: format, File: path})
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = log.Close() })
+
+	return log, func() string {
+		data, err := os.ReadFile(path)
+		assert.NoError(t, err)
+		return string(data)
+	}
+}
+
+// Тестируем JSON формат с полями из контекста и скрытием секретов
+func TestJSONWithContextFields(t *testing.T) {
+	log, read := newFileLog(t, "info", FormatJSON)
+
+	ctx := ContextWith(context.Background(), RunID, "42")
+	log.InfoContext(ctx, "saved table", Table, "tag", Rows, 10, Duration, 1500*time.Millisecond, "token", "secret")
+
+	var entry map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(read()), &entry))
+	assert.Equal(t, "saved table", entry["msg"])
+	assert.Equal(t, "42", entry[RunID])
+	assert.Equal(t, "tag", entry[Table])
+	assert.Equal(t, float64(10), entry[Rows])
+	assert.Equal(t, "1.5s", entry[Duration])
+	assert.Equal(t, "[REDACTED]", entry["token"])
+}
+
+// Тестируем, что сообщения ниже уровня не выводятся
+func TestLevel(t *testing.T) {
+	log, read := newFileLog(t, "warn", FormatText)
+
+	log.Info("hidden")
+	log.Error("shown")
+
+	assert.NotContains(t, read(), "hidden")
+	assert.Contains(t, read(), "msg=shown")
+}
+
+// Тестируем читаемый формат
+func TestPretty(t *testing.T) {
+	log, read := newFileLog(t, "debug", FormatPretty)
+
+	runLog := log.With(RunID, "42")
+	runLog.Debug("saved chunk", Table, "transaction", "password", "secret", "comment", "two words")
+
+	line := strings.TrimSpace(read())
+	assert.Regexp(t, `^\d{2}:\d{2}:\d{2} DEBUG saved chunk`, line)
+	assert.Contains(t, line, "run_id=42 table=transaction password=[REDACTED]")
+	assert.Contains(t, line, `comment="two words"`)
+}
+
+// Тестируем ошибку для неизвестного уровня
+func TestInvalidLevel(t *testing.T) {
+	_, err := NewWithOptions(Options{Level: "verbose"})
+	assert.Error(t, err)
+}
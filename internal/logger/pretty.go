@@ -0,0 +1,101 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// prettyHandler выводит сообщения в удобном для чтения в консоли виде:
+// время, уровень, сообщение и поля key=value.
+type prettyHandler struct {
+	opts   *slog.HandlerOptions
+	mu     *sync.Mutex
+	w      io.Writer
+	attrs  []slog.Attr
+	groups []string
+}
+
+func newPrettyHandler(w io.Writer, opts *slog.HandlerOptions) *prettyHandler {
+	return &prettyHandler{opts: opts, mu: &sync.Mutex{}, w: w}
+}
+
+func (h *prettyHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.opts.Level.Level()
+}
+
+func (h *prettyHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	b.WriteString(r.Time.Format(time.TimeOnly))
+	fmt.Fprintf(&b, " %-5s %s", r.Level.String(), r.Message)
+
+	for _, a := range h.attrs {
+		h.writeAttr(&b, nil, a)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		h.writeAttr(&b, h.groups, a)
+		return true
+	})
+	b.WriteByte('\n')
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+// writeAttr записывает атрибут как key=value, раскрывая группы в ключи вида group.key.
+func (h *prettyHandler) writeAttr(b *strings.Builder, groups []string, a slog.Attr) {
+	if h.opts.ReplaceAttr != nil && a.Value.Kind() != slog.KindGroup {
+		a = h.opts.ReplaceAttr(groups, a)
+	}
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			groups = append(append([]string(nil), groups...), a.Key)
+		}
+		for _, ga := range a.Value.Group() {
+			h.writeAttr(b, groups, ga)
+		}
+		return
+	}
+
+	key := a.Key
+	if len(groups) > 0 {
+		key = strings.Join(groups, ".") + "." + key
+	}
+	value := a.Value.String()
+	if strings.ContainsAny(value, " =\"") {
+		value = fmt.Sprintf("%q", value)
+	}
+	fmt.Fprintf(b, " %s=%s", key, value)
+}
+
+func (h *prettyHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.attrs = append([]slog.Attr(nil), h.attrs...)
+	for _, a := range attrs {
+		if len(h.groups) > 0 {
+			a = slog.Attr{Key: strings.Join(h.groups, "."), Value: slog.GroupValue(a)}
+		}
+		h2.attrs = append(h2.attrs, a)
+	}
+	return &h2
+}
+
+func (h *prettyHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.groups = append(append([]string(nil), h.groups...), name)
+	return &h2
+}
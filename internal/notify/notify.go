@@ -0,0 +1,220 @@
+// Package notify отправляет уведомления о завершении запусков синхронизации: HTTP webhook с JSON
+// и команду, которой событие передается через stdin и переменные окружения.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/nemirlev/zenexport/internal/db/model"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Режимы отправки уведомлений.
+const (
+	// OnAll уведомлять о каждом запуске.
+	OnAll = "all"
+	// OnFailure уведомлять только о неудачных запусках.
+	OnFailure = "failure"
+	// OnAlert уведомлять, когда число неудачных запусков подряд достигает порога, и когда синхронизация
+	// после этого восстанавливается.
+	OnAlert = "alert"
+)
+
+// Modes содержит поддерживаемые режимы отправки.
+var Modes = []string{OnAll, OnFailure, OnAlert}
+
+// timeout ограничивает время отправки одного уведомления.
+const timeout = 10 * time.Second
+
+// Event событие о завершении запуска синхронизации профиля.
+type Event struct {
+	RunID      string            `json:"run_id"`
+	Profile    string            `json:"profile"`
+	Status     string            `json:"status"`
+	StartedAt  time.Time         `json:"started_at"`
+	FinishedAt time.Time         `json:"finished_at"`
+	Duration   float64           `json:"duration_seconds"`
+	Rows       map[string]uint64 `json:"rows"`
+	Error      string            `json:"error,omitempty"`
+	// ConsecutiveFailures количество неудачных запусков профиля подряд, включая этот.
+	ConsecutiveFailures int `json:"consecutive_failures"`
+	// Alert true, если число неудачных запусков подряд достигло порога.
+	Alert bool `json:"alert"`
+}
+
+// Notifier отправляет событие.
+type Notifier interface {
+	Notify(ctx context.Context, event Event) error
+}
+
+// Webhook отправляет событие POST запросом с JSON телом.
+type Webhook struct {
+	URL    string
+	Client *http.Client
+}
+
+func (w Webhook) Notify(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "zenexport")
+
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Command запускает команду через sh -c. Событие передается в stdin в формате JSON, а основные поля - в
+// переменных окружения ZENEXPORT_RUN_ID, ZENEXPORT_PROFILE, ZENEXPORT_STATUS, ZENEXPORT_DURATION,
+// ZENEXPORT_ROWS, ZENEXPORT_ERROR и ZENEXPORT_ALERT.
+type Command struct {
+	Command string
+}
+
+func (c Command) Notify(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	var rows uint64
+	for _, n := range event.Rows {
+		rows += n
+	}
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", c.Command)
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Env = append(os.Environ(),
+		"ZENEXPORT_RUN_ID="+event.RunID,
+		"ZENEXPORT_PROFILE="+event.Profile,
+		"ZENEXPORT_STATUS="+event.Status,
+		"ZENEXPORT_DURATION="+strconv.FormatFloat(event.Duration, 'f', 3, 64),
+		"ZENEXPORT_ROWS="+strconv.FormatUint(rows, 10),
+		"ZENEXPORT_ERROR="+event.Error,
+		"ZENEXPORT_ALERT="+strconv.FormatBool(event.Alert),
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("notify command failed: %w: %s", err, bytes.TrimSpace(out))
+	}
+	return nil
+}
+
+// Dispatcher решает, нужно ли уведомлять о запуске, и отправляет событие всем получателям.
+// Число неудачных запусков подряд считается для каждого профиля отдельно, пока работает программа.
+type Dispatcher struct {
+	notifiers []Notifier
+	mode      string
+	threshold int
+
+	mu       sync.Mutex
+	failures map[string]int
+}
+
+// NewDispatcher создает диспетчер. threshold - число неудачных запусков подряд, после которого событие
+// помечается как Alert; значение меньше 1 считается равным 1.
+func NewDispatcher(mode string, threshold int, notifiers ...Notifier) *Dispatcher {
+	if threshold < 1 {
+		threshold = 1
+	}
+	return &Dispatcher{
+		notifiers: notifiers,
+		mode:      mode,
+		threshold: threshold,
+		failures:  make(map[string]int),
+	}
+}
+
+// Enabled возвращает true, если настроен хотя бы один получатель.
+func (d *Dispatcher) Enabled() bool {
+	return d != nil && len(d.notifiers) > 0
+}
+
+// Notify отправляет событие о запуске run, если этого требует режим. Ошибки всех получателей объединяются,
+// но не прерывают отправку остальным.
+func (d *Dispatcher) Notify(ctx context.Context, run model.Run) error {
+	if !d.Enabled() {
+		return nil
+	}
+
+	event, send := d.event(run)
+	if !send {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var errs []error
+	for _, n := range d.notifiers {
+		if err := n.Notify(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// event строит событие и обновляет счетчик неудачных запусков профиля.
+func (d *Dispatcher) event(run model.Run) (Event, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	previous := d.failures[run.Profile]
+	if run.Status == model.RunFailed {
+		d.failures[run.Profile] = previous + 1
+	} else {
+		d.failures[run.Profile] = 0
+	}
+	failures := d.failures[run.Profile]
+
+	event := Event{
+		RunID:               run.ID,
+		Profile:             run.Profile,
+		Status:              run.Status,
+		StartedAt:           run.StartedAt,
+		FinishedAt:          run.FinishedAt,
+		Duration:            run.Duration().Seconds(),
+		Rows:                run.Rows,
+		Error:               run.Error,
+		ConsecutiveFailures: failures,
+		Alert:               failures >= d.threshold,
+	}
+
+	switch d.mode {
+	case OnFailure:
+		return event, failures > 0
+	case OnAlert:
+		// Уведомление при достижении порога и при восстановлении после него
+		recovered := failures == 0 && previous >= d.threshold
+		return event, failures == d.threshold || recovered
+	default:
+		return event, true
+	}
+}
@@ -0,0 +1,112 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/nemirlev/zenexport/internal/db/model"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// recorder запоминает отправленные события
+type recorder struct {
+	events []Event
+}
+
+func (r *recorder) Notify(_ context.Context, event Event) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+func newRun(profile, status string) model.Run {
+	start := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	return model.Run{
+		ID:         "42",
+		Profile:    profile,
+		StartedAt:  start,
+		FinishedAt: start.Add(1500 * time.Millisecond),
+		Status:     status,
+		Rows:       map[string]uint64{"tag": 2, "transaction": 3},
+	}
+}
+
+// Тестируем отправку JSON в webhook
+func TestWebhook(t *testing.T) {
+	var got Event
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+	}))
+	defer server.Close()
+
+	d := NewDispatcher(OnAll, 3, Webhook{URL: server.URL})
+	assert.NoError(t, d.Notify(context.Background(), newRun("home", model.RunSuccess)))
+
+	assert.Equal(t, "42", got.RunID)
+	assert.Equal(t, "success", got.Status)
+	assert.Equal(t, 1.5, got.Duration)
+	assert.Equal(t, map[string]uint64{"tag": 2, "transaction": 3}, got.Rows)
+}
+
+// Тестируем ошибку при ответе webhook с кодом ошибки
+func TestWebhookError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	err := Webhook{URL: server.URL}.Notify(context.Background(), Event{})
+	assert.EqualError(t, err, "webhook returned 502 Bad Gateway")
+}
+
+// Тестируем передачу события команде через переменные окружения и stdin
+func TestCommand(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out")
+	cmd := Command{Command: `echo "$ZENEXPORT_PROFILE $ZENEXPORT_STATUS $ZENEXPORT_ROWS" > ` + out + `; cat >> ` + out}
+
+	assert.NoError(t, cmd.Notify(context.Background(), Event{Profile: "home", Status: "failed", Rows: map[string]uint64{"tag": 5}}))
+
+	data, err := os.ReadFile(out)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), "home failed 5\n{")
+
+	err = Command{Command: "echo oops; exit 3"}.Notify(context.Background(), Event{})
+	assert.EqualError(t, err, "notify command failed: exit status 3: oops")
+}
+
+// Тестируем режим failure
+func TestDispatcherOnFailure(t *testing.T) {
+	r := &recorder{}
+	d := NewDispatcher(OnFailure, 3, r)
+
+	assert.NoError(t, d.Notify(context.Background(), newRun("home", model.RunSuccess)))
+	assert.NoError(t, d.Notify(context.Background(), newRun("home", model.RunFailed)))
+	assert.NoError(t, d.Notify(context.Background(), newRun("home", model.RunFailed)))
+
+	assert.Len(t, r.events, 2)
+	assert.Equal(t, 2, r.events[1].ConsecutiveFailures)
+	assert.False(t, r.events[1].Alert)
+}
+
+// Тестируем режим alert: уведомление при достижении порога и при восстановлении, счетчики по профилям
+func TestDispatcherOnAlert(t *testing.T) {
+	r := &recorder{}
+	d := NewDispatcher(OnAlert, 2, r)
+
+	for _, status := range []string{model.RunFailed, model.RunFailed, model.RunFailed, model.RunSuccess, model.RunSuccess} {
+		assert.NoError(t, d.Notify(context.Background(), newRun("home", status)))
+	}
+	assert.NoError(t, d.Notify(context.Background(), newRun("work", model.RunFailed)))
+
+	assert.Len(t, r.events, 2)
+	assert.True(t, r.events[0].Alert)
+	assert.Equal(t, 2, r.events[0].ConsecutiveFailures)
+	assert.Equal(t, model.RunSuccess, r.events[1].Status)
+	assert.Equal(t, 0, r.events[1].ConsecutiveFailures)
+}
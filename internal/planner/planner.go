@@ -0,0 +1,149 @@
+// Package planner выполняет набор задач с зависимостями параллельно, ограничивая число одновременно работающих задач.
+package planner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrDependencyFailed возвращается для задачи, которая не была запущена из-за ошибки в одной из ее зависимостей.
+var ErrDependencyFailed = errors.New("dependency failed")
+
+type task struct {
+	name      string
+	dependsOn []string
+	run       func(ctx context.Context) error
+}
+
+// Plan описывает набор задач и порядок их выполнения.
+type Plan struct {
+	workers int
+	tasks   []task
+}
+
+// New создает план с ограничением на количество одновременно выполняемых задач.
+// Если workers меньше единицы, задачи выполняются последовательно.
+func New(workers int) *Plan {
+	if workers < 1 {
+		workers = 1
+	}
+	return &Plan{workers: workers}
+}
+
+// Add добавляет в план задачу name, которая будет запущена только после успешного завершения задач dependsOn.
+func (p *Plan) Add(name string, run func(ctx context.Context) error, dependsOn ...string) {
+	p.tasks = append(p.tasks, task{name: name, dependsOn: dependsOn, run: run})
+}
+
+// Run выполняет все задачи плана. Независимые задачи выполняются параллельно, но не более workers одновременно.
+// Ошибка одной задачи не останавливает остальные, а зависящие от нее задачи пропускаются.
+// Возвращает объединенную ошибку всех задач, каждая из которых помечена именем задачи.
+func (p *Plan) Run(ctx context.Context) error {
+	if err := p.validate(); err != nil {
+		return err
+	}
+
+	results := make(map[string]*result, len(p.tasks))
+	for _, t := range p.tasks {
+		results[t.name] = &result{done: make(chan struct{})}
+	}
+
+	sem := make(chan struct{}, p.workers)
+	for _, t := range p.tasks {
+		go func(t task) {
+			res := results[t.name]
+			res.err = p.runTask(ctx, t, sem, results)
+			close(res.done)
+		}(t)
+	}
+
+	var errs []error
+	for _, t := range p.tasks {
+		res := results[t.name]
+		<-res.done
+		if res.err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", t.name, res.err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// result хранит итог выполнения задачи. Поле err записывается до закрытия done,
+// поэтому его можно читать без блокировок после ожидания канала.
+type result struct {
+	done chan struct{}
+	err  error
+}
+
+// runTask дожидается зависимостей задачи, занимает слот воркера и выполняет задачу.
+func (p *Plan) runTask(ctx context.Context, t task, sem chan struct{}, results map[string]*result) error {
+	for _, dep := range t.dependsOn {
+		res := results[dep]
+		<-res.done
+		if res.err != nil {
+			return fmt.Errorf("%w: %s", ErrDependencyFailed, dep)
+		}
+	}
+
+	select {
+	case sem <- struct{}{}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	defer func() { <-sem }()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return t.run(ctx)
+}
+
+// validate проверяет, что все зависимости существуют, имена задач уникальны и в плане нет циклов.
+func (p *Plan) validate() error {
+	deps := make(map[string][]string, len(p.tasks))
+	for _, t := range p.tasks {
+		if _, ok := deps[t.name]; ok {
+			return fmt.Errorf("duplicate task %q", t.name)
+		}
+		deps[t.name] = t.dependsOn
+	}
+
+	for _, t := range p.tasks {
+		for _, dep := range t.dependsOn {
+			if _, ok := deps[dep]; !ok {
+				return fmt.Errorf("task %q depends on unknown task %q", t.name, dep)
+			}
+		}
+	}
+
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	state := make(map[string]int, len(p.tasks))
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visiting:
+			return fmt.Errorf("dependency cycle detected at task %q", name)
+		case visited:
+			return nil
+		}
+		state[name] = visiting
+		for _, dep := range deps[name] {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+		return nil
+	}
+
+	for _, t := range p.tasks {
+		if err := visit(t.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
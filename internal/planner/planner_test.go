@@ -0,0 +1,104 @@
+package planner
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Проверяем, что задача запускается только после своих зависимостей
+func TestRunRespectsDependencies(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+	record := func(name string) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+			return nil
+		}
+	}
+
+	p := New(4)
+	p.Add("transaction", record("transaction"), "account", "tag")
+	p.Add("account", record("account"), "instrument")
+	p.Add("tag", record("tag"))
+	p.Add("instrument", record("instrument"))
+
+	assert.NoError(t, p.Run(context.Background()))
+	assert.Len(t, order, 4)
+
+	pos := make(map[string]int)
+	for i, name := range order {
+		pos[name] = i
+	}
+	assert.Less(t, pos["instrument"], pos["account"])
+	assert.Less(t, pos["account"], pos["transaction"])
+	assert.Less(t, pos["tag"], pos["transaction"])
+}
+
+// Проверяем, что одновременно выполняется не больше задач, чем воркеров
+func TestRunLimitsWorkers(t *testing.T) {
+	var running, peak int32
+	work := func(ctx context.Context) error {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			old := atomic.LoadInt32(&peak)
+			if n <= old || atomic.CompareAndSwapInt32(&peak, old, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return nil
+	}
+
+	p := New(2)
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		p.Add(name, work)
+	}
+
+	assert.NoError(t, p.Run(context.Background()))
+	assert.LessOrEqual(t, atomic.LoadInt32(&peak), int32(2))
+}
+
+// Проверяем, что ошибки всех задач собираются в одну, а зависимые задачи пропускаются
+func TestRunAggregatesErrors(t *testing.T) {
+	errA := errors.New("a failed")
+	errB := errors.New("b failed")
+	var dependentRan bool
+
+	p := New(2)
+	p.Add("a", func(ctx context.Context) error { return errA })
+	p.Add("b", func(ctx context.Context) error { return errB })
+	p.Add("c", func(ctx context.Context) error {
+		dependentRan = true
+		return nil
+	}, "a")
+
+	err := p.Run(context.Background())
+	assert.ErrorIs(t, err, errA)
+	assert.ErrorIs(t, err, errB)
+	assert.ErrorIs(t, err, ErrDependencyFailed)
+	assert.False(t, dependentRan)
+}
+
+// Проверяем обнаружение циклов и неизвестных зависимостей
+func TestRunValidatesPlan(t *testing.T) {
+	noop := func(ctx context.Context) error { return nil }
+
+	p := New(1)
+	p.Add("a", noop, "b")
+	p.Add("b", noop, "a")
+	assert.Error(t, p.Run(context.Background()))
+
+	p = New(1)
+	p.Add("a", noop, "missing")
+	assert.Error(t, p.Run(context.Background()))
+}
@@ -0,0 +1,106 @@
+// Package tracing настраивает трассировку OpenTelemetry: спаны запусков синхронизации, загрузки данных из ZenMoney
+// и записи таблиц экспортируются по OTLP или в stdout.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Экспортеры спанов.
+const (
+	ExporterNone   = "none"
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+)
+
+// Exporters содержит поддерживаемые экспортеры.
+var Exporters = []string{ExporterNone, ExporterOTLP, ExporterStdout}
+
+// serviceName имя сервиса и трассировщика в спанах.
+const serviceName = "zenexport"
+
+// Атрибуты спанов.
+const (
+	AttrRunID    = attribute.Key("zenexport.run_id")
+	AttrProfile  = attribute.Key("zenexport.profile")
+	AttrTable    = attribute.Key("db.sql.table")
+	AttrRows     = attribute.Key("zenexport.rows")
+	AttrQueryIDs = attribute.Key("clickhouse.query_ids")
+)
+
+// Options настройки трассировки.
+type Options struct {
+	// Exporter куда отправлять спаны: none, otlp или stdout.
+	Exporter string
+	// Endpoint адрес OTLP/HTTP коллектора, например http://localhost:4318. Если не задан, используются
+	// стандартные переменные OTEL_EXPORTER_OTLP_ENDPOINT и OTEL_EXPORTER_OTLP_TRACES_ENDPOINT.
+	Endpoint string
+}
+
+// Setup настраивает глобальный провайдер трассировки и возвращает функцию, которая отправляет оставшиеся спаны
+// и останавливает провайдер. Если экспортер не задан, спаны не создаются.
+func Setup(ctx context.Context, opts Options) (func(ctx context.Context) error, error) {
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch opts.Exporter {
+	case "", ExporterNone:
+		return func(ctx context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		var httpOpts []otlptracehttp.Option
+		if opts.Endpoint != "" {
+			httpOpts = append(httpOpts, otlptracehttp.WithEndpointURL(opts.Endpoint))
+		}
+		exporter, err = otlptracehttp.New(ctx, httpOpts...)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithPrettyPrint())
+	default:
+		return nil, fmt.Errorf("unsupported tracing exporter %q", opts.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tracing exporter: %w", err)
+	}
+
+	// Имя сервиса можно переопределить переменными OTEL_SERVICE_NAME и OTEL_RESOURCE_ATTRIBUTES
+	res, err := resource.New(ctx,
+		resource.WithTelemetrySDK(),
+		resource.WithAttributes(attribute.String("service.name", serviceName)),
+		resource.WithFromEnv(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return provider.Shutdown, nil
+}
+
+// Start начинает спан с именем name. Без вызова Setup спан ничего не записывает.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(serviceName).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End завершает спан, помечая его ошибкой, если err не nil. Удобно вызывать в defer с именованной ошибкой.
+func End(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, context.Canceled) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,45 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"testing"
+)
+
+// Тестируем, что без экспортера трассировка выключена
+func TestSetupNone(t *testing.T) {
+	shutdown, err := Setup(context.Background(), Options{Exporter: ExporterNone})
+	assert.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+
+	_, err = Setup(context.Background(), Options{Exporter: "jaeger"})
+	assert.EqualError(t, err, `unsupported tracing exporter "jaeger"`)
+}
+
+// Тестируем вложенность спанов, атрибуты и статус ошибки
+func TestStartEnd(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	ctx, run := Start(context.Background(), "zenexport.sync", AttrRunID.String("42"))
+	_, write := Start(ctx, "clickhouse.write tag", AttrTable.String("tag"), AttrRows.Int(3))
+	write.SetAttributes(AttrQueryIDs.StringSlice([]string{"q1", "q2"}))
+	End(write, errors.New("boom"))
+	End(run, nil)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 2)
+	assert.Equal(t, "clickhouse.write tag", spans[0].Name())
+	assert.Equal(t, spans[1].SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.Contains(t, spans[0].Attributes(), AttrQueryIDs.StringSlice([]string{"q1", "q2"}))
+	assert.Equal(t, codes.Unset, spans[1].Status().Code)
+}
@@ -2,12 +2,25 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"github.com/google/uuid"
 	"github.com/nemirlev/zenapi"
 	"github.com/nemirlev/zenexport/internal/config"
 	"github.com/nemirlev/zenexport/internal/db"
+	"github.com/nemirlev/zenexport/internal/db/model"
+	"github.com/nemirlev/zenexport/internal/dryrun"
+	"github.com/nemirlev/zenexport/internal/entity"
 	"github.com/nemirlev/zenexport/internal/logger"
+	"github.com/nemirlev/zenexport/internal/notify"
+	"github.com/nemirlev/zenexport/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 	"time"
 )
 
@@ -15,75 +28,250 @@ func createClient(token string) (*zenapi.Client, error) {
 	return zenapi.NewClient(token)
 }
 
-func runSyncAndSave(log logger.Log, client *zenapi.Client, db db.DataStore) error {
-	fmt.Println("Get data from ZenMoney...")
+// profileClient клиент ZenMoney для одного профиля.
+type profileClient struct {
+	label  string
+	client *zenapi.Client
+}
+
+// createClients создает клиентов для всех профилей из конфигурации.
+func createClients(profiles []config.Profile) ([]profileClient, error) {
+	clients := make([]profileClient, 0, len(profiles))
+	for _, profile := range profiles {
+		client, err := createClient(profile.Token)
+		if err != nil {
+			return nil, fmt.Errorf("profile %s: %w", profile.Label, err)
+		}
+		clients = append(clients, profileClient{label: profile.Label, client: client})
+	}
+	return clients, nil
+}
+
+// runAllProfiles синхронизирует все профили по очереди. Ошибка одного профиля не мешает синхронизации остальных.
+func runAllProfiles(ctx context.Context, log logger.Log, clients []profileClient, db db.DataStore, notifier *notify.Dispatcher) error {
+	var errs []error
+	for _, pc := range clients {
+		if err := runSyncAndSave(ctx, log, pc.client, pc.label, db, notifier); err != nil {
+			errs = append(errs, fmt.Errorf("profile %s: %w", pc.label, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// runDryRun получает данные всех профилей из ZenMoney и выводит, сколько строк в каждой таблице будет вставлено,
+// обновлено и удалено. В базу данных ничего не записывается. Если задан reportPath, отчет сохраняется в JSON.
+func runDryRun(ctx context.Context, log logger.Log, cfg *config.Config, clients []profileClient, db db.DataStore) error {
+	filter, err := entity.NewFilter(cfg.IncludeEntities, cfg.ExcludeEntities)
+	if err != nil {
+		return err
+	}
+	var tables []string
+	for _, name := range entity.All {
+		if filter.Enabled(name) {
+			tables = append(tables, name)
+		}
+	}
+
+	period, err := entity.ParsePeriod(cfg.Since, cfg.Until)
+	if err != nil {
+		return err
+	}
+
+	report := dryrun.Report{GeneratedAt: time.Now()}
+	for _, pc := range clients {
+		log.Info("dry run started", logger.Profile, pc.label)
+		data, err := pc.client.FullSync()
+		if err != nil {
+			return fmt.Errorf("profile %s: %w", pc.label, err)
+		}
+		stored, err := db.Versions(ctx, pc.label)
+		if err != nil {
+			return fmt.Errorf("profile %s: %w", pc.label, err)
+		}
+		report.Tables = append(report.Tables, dryrun.Compare(pc.label, tables, entity.Versions(entity.FilterPeriod(&data, period)), stored)...)
+	}
+
+	if err := dryrun.Print(os.Stdout, report.Tables); err != nil {
+		return err
+	}
+	if cfg.DryRunReport != "" {
+		if err := dryrun.WriteReport(cfg.DryRunReport, report); err != nil {
+			return err
+		}
+		log.Info("dry-run report saved", "path", cfg.DryRunReport)
+	}
+	return nil
+}
+
+// runSyncAndSave получает данные профиля из ZenMoney, сохраняет их в базу данных, записывает результат запуска
+// и отправляет уведомление о нем.
+func runSyncAndSave(ctx context.Context, log logger.Log, client *zenapi.Client, profile string, db db.DataStore, notifier *notify.Dispatcher) error {
+	run := model.Run{
+		ID:        uuid.NewString(),
+		Profile:   profile,
+		StartedAt: time.Now(),
+		Status:    model.RunSuccess,
+	}
+	// Поля запуска добавляются ко всем сообщениям, в том числе к сообщениям хранилища
+	ctx = logger.ContextWith(ctx, logger.RunID, run.ID, logger.Profile, profile)
+	ctx, span := tracing.Start(ctx, "zenexport.sync",
+		tracing.AttrRunID.String(run.ID),
+		tracing.AttrProfile.String(profile),
+	)
+	log.InfoContext(ctx, "sync started")
+
+	err := syncAndSave(ctx, log, client, profile, db, &run)
+
+	run.FinishedAt = time.Now()
+	if err != nil {
+		run.Status = model.RunFailed
+		run.Error = err.Error()
+	}
+	if err := db.RecordRun(ctx, run); err != nil {
+		log.WithErrorContext(ctx, err, "failed to record sync run")
+	}
+
+	var rows uint64
+	for _, n := range run.Rows {
+		rows += n
+	}
+	span.SetAttributes(tracing.AttrRows.Int64(int64(rows)))
+	tracing.End(span, err)
+	log.InfoContext(ctx, "sync finished", "status", run.Status, logger.Rows, rows, logger.Duration, run.Duration())
+
+	// Ошибка отправки уведомления не влияет на результат синхронизации
+	if err := notifier.Notify(ctx, run); err != nil {
+		log.WithErrorContext(ctx, err, "failed to send notification")
+	}
+	return err
+}
+
+func syncAndSave(ctx context.Context, log logger.Log, client *zenapi.Client, profile string, db db.DataStore, run *model.Run) error {
+	start := time.Now()
+	_, span := tracing.Start(ctx, "zenmoney.fetch")
 	resBody, err := client.FullSync()
-	fmt.Println("Finished getting data from ZenMoney.")
 	if err != nil {
-		log.WithError(err, "error getting ZenMoney data")
+		tracing.End(span, err)
+		log.WithErrorContext(ctx, err, "error getting ZenMoney data")
 		return err
 	}
+	run.Rows = entity.Counts(&resBody)
+	for name, rows := range run.Rows {
+		span.SetAttributes(attribute.Int64("zenmoney.rows."+name, int64(rows)))
+	}
+	tracing.End(span, nil)
+	log.InfoContext(ctx, "fetched data from ZenMoney", logger.Duration, time.Since(start))
 
-	fmt.Println("Save data to Database...")
-	err = db.Save(&resBody)
+	start = time.Now()
+	err = db.Save(ctx, profile, &resBody)
 	if err != nil {
-		log.WithError(err, "error save ZenMoney data to DB")
+		log.WithErrorContext(ctx, err, "error save ZenMoney data to DB")
 		return err
 	}
-	fmt.Println("Import completed.")
+	log.InfoContext(ctx, "saved data to database", logger.Duration, time.Since(start))
 	return nil
 }
 
 func main() {
 	log := logger.New()
-	cfg, err := config.FromEnv()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	err := run(ctx, os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
 	if err != nil {
-		log.WithError(err, "get cfg")
+		log.WithError(err, "zenexport failed")
+		stop()
 		os.Exit(1)
 	}
+}
+
+// run выполняет команду из args. Если первый аргумент не команда, а флаг, работает как раньше:
+// с флагом -d запускается демон, без него - однократная синхронизация.
+func run(ctx context.Context, args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return runLegacy(ctx)
+	}
+
+	name := args[0]
+	if name == "help" {
+		printUsage(os.Stdout)
+		return nil
+	}
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd.run(ctx, args[1:])
+		}
+	}
+
+	printUsage(os.Stderr)
+	return fmt.Errorf("unknown command %q", name)
+}
 
-	client, err := createClient(cfg.ZenMoneyToken)
+// runLegacy запускает экспорт с флагами из глобального набора, как до появления команд.
+func runLegacy(ctx context.Context) error {
+	flag.Usage = func() {
+		printUsage(flag.CommandLine.Output())
+		fmt.Fprintln(flag.CommandLine.Output(), "\nFlags without a command:")
+		flag.PrintDefaults()
+	}
+
+	cfg, err := config.FromEnv()
 	if err != nil {
-		log.WithError(err, "failed to create client")
-		os.Exit(1)
+		return err
 	}
 
-	dbase, err := db.NewDataStore(cfg, log)
+	a, err := newApp(ctx, cfg, true)
 	if err != nil {
-		log.WithError(err, "failed to setup database")
-		os.Exit(1)
+		return err
+	}
+	defer a.close()
+
+	if cfg.DryRun {
+		return runDryRun(ctx, a.log, cfg, a.clients, a.store)
+	}
+	if cfg.Migrate {
+		if err := a.store.Migrate(ctx); err != nil {
+			return err
+		}
 	}
 
 	if cfg.IsDaemon {
-		interval := time.Duration(cfg.Interval) * time.Minute
-
-		ticker := time.NewTicker(interval)
-
-		for range ticker.C {
-			start := time.Now()
-			err := runSyncAndSave(log, client, dbase)
-			if err != nil {
-				log.WithError(err, "error sync ZenMoney data")
-			}
-
-			nextTick := start.Add(interval)
-
-			timer := time.NewTicker(1 * time.Second)
-			go func() {
-				for range timer.C {
-					timeLeft := time.Until(nextTick).Round(time.Second)
-					fmt.Printf("\rNext run in %v", timeLeft)
-				}
-			}()
-
-			<-time.After(time.Until(nextTick))
-			timer.Stop()
-			fmt.Println()
+		runDaemon(ctx, a.log, a.clients, a.store, a.notifier, time.Duration(cfg.Interval)*time.Minute)
+		return nil
+	}
+	return runAllProfiles(ctx, a.log, a.clients, a.store, a.notifier)
+}
+
+// runDaemon запускает синхронизацию всех профилей каждые interval, пока не будет получен сигнал остановки.
+// Соединение с базой данных и счетчики неудачных запусков для уведомлений переиспользуются между запусками.
+func runDaemon(ctx context.Context, log logger.Log, clients []profileClient, dbase db.DataStore, notifier *notify.Dispatcher, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info("shutting down daemon")
+			return
+		case <-ticker.C:
 		}
-	} else {
-		err := runSyncAndSave(log, client, dbase)
+
+		start := time.Now()
+		err := runAllProfiles(ctx, log, clients, dbase, notifier)
 		if err != nil {
 			log.WithError(err, "error sync ZenMoney data")
 		}
+
+		nextTick := start.Add(interval)
+		log.Info("next run scheduled", "next_run", nextTick.Format(time.DateTime), logger.Duration, time.Until(nextTick))
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Until(nextTick)):
+		}
 	}
 }
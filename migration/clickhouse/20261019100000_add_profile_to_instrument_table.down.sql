@@ -0,0 +1,2 @@
+ALTER TABLE instrument
+    DROP COLUMN IF EXISTS profile;
@@ -0,0 +1,2 @@
+ALTER TABLE tag
+    DROP COLUMN IF EXISTS profile;
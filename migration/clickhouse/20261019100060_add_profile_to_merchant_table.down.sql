@@ -0,0 +1,2 @@
+ALTER TABLE merchant
+    DROP COLUMN IF EXISTS profile;
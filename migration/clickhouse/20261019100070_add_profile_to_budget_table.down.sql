@@ -0,0 +1,2 @@
+ALTER TABLE budget
+    DROP COLUMN IF EXISTS profile;
@@ -0,0 +1,2 @@
+ALTER TABLE reminder
+    DROP COLUMN IF EXISTS profile;
@@ -0,0 +1,2 @@
+ALTER TABLE reminder
+    ADD COLUMN IF NOT EXISTS profile LowCardinality(String) DEFAULT 'default';
@@ -0,0 +1,2 @@
+ALTER TABLE reminder_marker
+    DROP COLUMN IF EXISTS profile;
@@ -0,0 +1,2 @@
+ALTER TABLE reminder_marker
+    ADD COLUMN IF NOT EXISTS profile LowCardinality(String) DEFAULT 'default';
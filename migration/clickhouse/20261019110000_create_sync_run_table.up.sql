@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS sync_run
+(
+    run_id      UUID,
+    profile     LowCardinality(String),
+    started_at  DateTime64(3),
+    finished_at DateTime64(3),
+    status      LowCardinality(String),
+    error       String,
+    rows        Map(String, UInt64)
+) ENGINE = MergeTree ORDER BY (profile, started_at);
@@ -0,0 +1 @@
+RENAME TABLE budget_unpartitioned TO budget;
@@ -0,0 +1 @@
+RENAME TABLE budget TO budget_unpartitioned;
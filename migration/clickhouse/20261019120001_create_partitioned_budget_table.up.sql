@@ -0,0 +1,2 @@
+CREATE TABLE IF NOT EXISTS budget AS budget_unpartitioned
+    ENGINE = MergeTree PARTITION BY (profile, toYear(toDateOrZero(date))) ORDER BY date;
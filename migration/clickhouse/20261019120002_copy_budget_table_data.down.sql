@@ -0,0 +1 @@
+INSERT INTO budget_unpartitioned SELECT * FROM budget;
@@ -0,0 +1 @@
+INSERT INTO budget SELECT * FROM budget_unpartitioned;
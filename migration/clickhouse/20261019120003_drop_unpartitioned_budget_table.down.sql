@@ -0,0 +1,2 @@
+CREATE TABLE IF NOT EXISTS budget_unpartitioned AS budget
+    ENGINE = MergeTree ORDER BY date;
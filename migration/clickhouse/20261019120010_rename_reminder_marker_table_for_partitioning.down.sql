@@ -0,0 +1 @@
+RENAME TABLE reminder_marker_unpartitioned TO reminder_marker;
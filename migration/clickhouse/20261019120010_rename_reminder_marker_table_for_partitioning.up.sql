@@ -0,0 +1 @@
+RENAME TABLE reminder_marker TO reminder_marker_unpartitioned;
@@ -0,0 +1,2 @@
+CREATE TABLE IF NOT EXISTS reminder_marker AS reminder_marker_unpartitioned
+    ENGINE = MergeTree PARTITION BY (profile, toYear(toDateOrZero(date))) PRIMARY KEY id;
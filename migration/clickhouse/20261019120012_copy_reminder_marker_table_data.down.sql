@@ -0,0 +1 @@
+INSERT INTO reminder_marker_unpartitioned SELECT * FROM reminder_marker;
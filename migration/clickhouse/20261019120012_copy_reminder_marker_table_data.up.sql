@@ -0,0 +1 @@
+INSERT INTO reminder_marker SELECT * FROM reminder_marker_unpartitioned;
@@ -0,0 +1,2 @@
+CREATE TABLE IF NOT EXISTS reminder_marker_unpartitioned AS reminder_marker
+    ENGINE = MergeTree PRIMARY KEY id;
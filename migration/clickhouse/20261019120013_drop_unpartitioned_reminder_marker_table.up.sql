@@ -0,0 +1 @@
+DROP TABLE IF EXISTS reminder_marker_unpartitioned;
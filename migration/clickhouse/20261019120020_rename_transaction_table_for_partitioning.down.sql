@@ -0,0 +1 @@
+RENAME TABLE transaction_unpartitioned TO transaction;
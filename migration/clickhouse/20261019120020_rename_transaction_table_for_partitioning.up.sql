@@ -0,0 +1 @@
+RENAME TABLE transaction TO transaction_unpartitioned;
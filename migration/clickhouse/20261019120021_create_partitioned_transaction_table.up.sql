@@ -0,0 +1,2 @@
+CREATE TABLE IF NOT EXISTS transaction AS transaction_unpartitioned
+    ENGINE = MergeTree PARTITION BY (profile, toYear(toDateOrZero(date))) PRIMARY KEY id;
@@ -0,0 +1 @@
+INSERT INTO transaction_unpartitioned SELECT * FROM transaction;
@@ -0,0 +1 @@
+INSERT INTO transaction SELECT * FROM transaction_unpartitioned;
@@ -0,0 +1,2 @@
+CREATE TABLE IF NOT EXISTS transaction_unpartitioned AS transaction
+    ENGINE = MergeTree PRIMARY KEY id;
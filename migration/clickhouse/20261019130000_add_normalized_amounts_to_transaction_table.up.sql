@@ -0,0 +1,4 @@
+ALTER TABLE transaction
+    ADD COLUMN IF NOT EXISTS currency Nullable(Int32),
+    ADD COLUMN IF NOT EXISTS income_normalized Nullable(Float64),
+    ADD COLUMN IF NOT EXISTS outcome_normalized Nullable(Float64);
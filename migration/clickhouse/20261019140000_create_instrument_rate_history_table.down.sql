@@ -0,0 +1 @@
+DROP TABLE IF EXISTS instrument_rate_history;
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS instrument_rate_history
+(
+    instrument  Int32,
+    rate        Float64,
+    observed_at DateTime,
+    profile     LowCardinality(String)
+) ENGINE = MergeTree PARTITION BY (profile, toYYYYMM(observed_at)) ORDER BY (profile, instrument, observed_at);
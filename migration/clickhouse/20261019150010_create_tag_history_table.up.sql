@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS tag_history
+(
+    id            UUID,
+    changed       Int32,
+    user          Int32,
+    title         String,
+    parent        Nullable(String),
+    icon          Nullable(String),
+    picture       Nullable(String),
+    color         Nullable(Int64),
+    show_income    UInt8,
+    show_outcome   UInt8,
+    budget_income  UInt8,
+    budget_outcome UInt8,
+    required      Nullable(BOOL),
+    profile       LowCardinality(String),
+    valid_from    DateTime,
+    valid_to      Nullable(DateTime)
+) ENGINE = MergeTree ORDER BY (profile, id, valid_from);
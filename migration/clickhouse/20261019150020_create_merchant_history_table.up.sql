@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS merchant_history
+(
+    id         UUID,
+    changed    Int32,
+    user       Int32,
+    title      String,
+    profile    LowCardinality(String),
+    valid_from DateTime,
+    valid_to   Nullable(DateTime)
+) ENGINE = MergeTree ORDER BY (profile, id, valid_from);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS account_balance_daily
+(
+    account    UUID,
+    user       Int32,
+    instrument Nullable(Int32),
+    date       Date,
+    income     Float64,
+    outcome    Float64,
+    balance    Float64,
+    profile    LowCardinality(String)
+) ENGINE = MergeTree PARTITION BY profile ORDER BY (profile, account, date);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS reconciliation
+(
+    account    UUID,
+    title      String,
+    instrument Nullable(Int32),
+    balance    Float64,
+    computed   Float64,
+    difference Float64,
+    tolerance  Float64,
+    status     LowCardinality(String),
+    checked_at DateTime,
+    profile    LowCardinality(String)
+) ENGINE = MergeTree PARTITION BY profile ORDER BY (profile, account);
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS ledger_entry
+(
+    transaction_id UUID,
+    account        UUID,
+    instrument     Int32,
+    user           Int32,
+    date           Date,
+    kind           LowCardinality(String),
+    amount         Float64,
+    tag            Nullable(UUID),
+    merchant       Nullable(UUID),
+    payee          String,
+    profile        LowCardinality(String)
+) ENGINE = MergeTree PARTITION BY profile ORDER BY (profile, date, account, transaction_id);
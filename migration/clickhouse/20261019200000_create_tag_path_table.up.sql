@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS tag_path
+(
+    tag               UUID,
+    user              Int32,
+    title             String,
+    root              UUID,
+    root_title        String,
+    subcategory       Nullable(UUID),
+    subcategory_title Nullable(String),
+    path              String,
+    depth             UInt8,
+    profile           LowCardinality(String)
+) ENGINE = MergeTree PARTITION BY profile ORDER BY (profile, tag);
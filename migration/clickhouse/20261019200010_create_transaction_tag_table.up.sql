@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS transaction_tag
+(
+    transaction_id UUID,
+    tag            UUID,
+    position       UInt8,
+    is_primary     BOOL,
+    profile        LowCardinality(String)
+) ENGINE = MergeTree PARTITION BY profile ORDER BY (profile, tag, transaction_id);
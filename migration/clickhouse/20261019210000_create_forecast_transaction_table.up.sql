@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS forecast_transaction
+(
+    reminder           UUID,
+    user               Int32,
+    date               Date,
+    income_instrument  Int32,
+    income_account     String,
+    income             Float64,
+    outcome_instrument Int32,
+    outcome_account    String,
+    outcome            Float64,
+    tag                Array(UUID),
+    merchant           Nullable(UUID),
+    payee              String,
+    comment            String,
+    reminder_marker    Nullable(UUID),
+    marker_state       Nullable(String),
+    profile            LowCardinality(String)
+) ENGINE = MergeTree PARTITION BY profile ORDER BY (profile, date, reminder);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS budget_actual
+(
+    user             Int32,
+    tag              Nullable(UUID),
+    month            Date,
+    planned_income   Float64,
+    actual_income    Float64,
+    income_variance  Float64,
+    planned_outcome  Float64,
+    actual_outcome   Float64,
+    outcome_variance Float64,
+    profile          LowCardinality(String)
+) ENGINE = MergeTree PARTITION BY profile ORDER BY (profile, user, month);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS instrument_local ON CLUSTER '{cluster}'
+(
+    id         Int32,
+    changed    Int32,
+    title      String,
+    short_title String,
+    symbol     String,
+    rate       Float64
+) ENGINE = ReplicatedMergeTree('/clickhouse/tables/{shard}/{database}/instrument_local', '{replica}') PRIMARY KEY id;
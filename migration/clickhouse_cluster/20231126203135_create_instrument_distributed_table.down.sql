@@ -0,0 +1 @@
+DROP TABLE IF EXISTS instrument ON CLUSTER '{cluster}' SYNC;
@@ -0,0 +1,2 @@
+CREATE TABLE IF NOT EXISTS instrument ON CLUSTER '{cluster}' AS instrument_local
+    ENGINE = Distributed('{cluster}', currentDatabase(), instrument_local, cityHash64(id));
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS company_local ON CLUSTER '{cluster}'
+(
+    id        Int32,
+    changed   Int32,
+    title     String,
+    full_title String,
+    www       String,
+    country   Int32
+) ENGINE = ReplicatedMergeTree('/clickhouse/tables/{shard}/{database}/company_local', '{replica}') PRIMARY KEY id;
@@ -0,0 +1,2 @@
+CREATE TABLE IF NOT EXISTS company ON CLUSTER '{cluster}' AS company_local
+    ENGINE = Distributed('{cluster}', currentDatabase(), company_local, cityHash64(id));
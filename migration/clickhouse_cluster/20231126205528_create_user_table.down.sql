@@ -0,0 +1 @@
+DROP TABLE IF EXISTS user_local ON CLUSTER '{cluster}' SYNC;
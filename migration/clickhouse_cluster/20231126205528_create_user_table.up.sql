@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS user_local ON CLUSTER '{cluster}'
+(
+    id       Int32,
+    changed  Int32,
+    login    Nullable(String),
+    currency Int32,
+    parent   Nullable(Int32)
+) ENGINE = ReplicatedMergeTree('/clickhouse/tables/{shard}/{database}/user_local', '{replica}') PRIMARY KEY id;
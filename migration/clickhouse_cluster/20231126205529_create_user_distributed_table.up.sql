@@ -0,0 +1,2 @@
+CREATE TABLE IF NOT EXISTS user ON CLUSTER '{cluster}' AS user_local
+    ENGINE = Distributed('{cluster}', currentDatabase(), user_local, cityHash64(id));
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS country_local ON CLUSTER '{cluster}'
+(
+    id       Int32,
+    title    String,
+    currency Int32,
+    domain   String
+) ENGINE = ReplicatedMergeTree('/clickhouse/tables/{shard}/{database}/country_local', '{replica}') PRIMARY KEY id;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS country ON CLUSTER '{cluster}' SYNC;
@@ -0,0 +1,2 @@
+CREATE TABLE IF NOT EXISTS country ON CLUSTER '{cluster}' AS country_local
+    ENGINE = Distributed('{cluster}', currentDatabase(), country_local, cityHash64(id));
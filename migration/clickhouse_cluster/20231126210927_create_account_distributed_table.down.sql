@@ -0,0 +1 @@
+DROP TABLE IF EXISTS account ON CLUSTER '{cluster}' SYNC;
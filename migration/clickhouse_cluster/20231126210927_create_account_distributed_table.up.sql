@@ -0,0 +1,2 @@
+CREATE TABLE IF NOT EXISTS account ON CLUSTER '{cluster}' AS account_local
+    ENGINE = Distributed('{cluster}', currentDatabase(), account_local, cityHash64(id));
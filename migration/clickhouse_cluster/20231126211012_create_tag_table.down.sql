@@ -0,0 +1 @@
+DROP TABLE IF EXISTS tag_local ON CLUSTER '{cluster}' SYNC;
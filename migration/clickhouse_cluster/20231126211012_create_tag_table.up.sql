@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS tag_local ON CLUSTER '{cluster}'
+(
+    id            UUID,
+    changed       Int32,
+    user          Int32,
+    title         String,
+    parent        Nullable(String),
+    icon          Nullable(String),
+    picture       Nullable(String),
+    color         Nullable(Int64),
+    show_income    UInt8,
+    show_outcome   UInt8,
+    budget_income  UInt8,
+    budget_outcome UInt8,
+    required      Nullable(BOOL)
+) ENGINE = ReplicatedMergeTree('/clickhouse/tables/{shard}/{database}/tag_local', '{replica}') PRIMARY KEY id;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS tag ON CLUSTER '{cluster}' SYNC;
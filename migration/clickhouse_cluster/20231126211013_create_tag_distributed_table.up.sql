@@ -0,0 +1,2 @@
+CREATE TABLE IF NOT EXISTS tag ON CLUSTER '{cluster}' AS tag_local
+    ENGINE = Distributed('{cluster}', currentDatabase(), tag_local, cityHash64(id));
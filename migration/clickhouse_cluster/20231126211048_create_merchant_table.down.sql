@@ -0,0 +1 @@
+DROP TABLE IF EXISTS merchant_local ON CLUSTER '{cluster}' SYNC;
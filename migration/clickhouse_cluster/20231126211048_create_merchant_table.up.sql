@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS merchant_local ON CLUSTER '{cluster}'
+(
+    id      UUID,
+    changed Int32,
+    user    Int32,
+    title   String
+) ENGINE = ReplicatedMergeTree('/clickhouse/tables/{shard}/{database}/merchant_local', '{replica}') PRIMARY KEY id;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS merchant ON CLUSTER '{cluster}' SYNC;
@@ -0,0 +1,2 @@
+CREATE TABLE IF NOT EXISTS merchant ON CLUSTER '{cluster}' AS merchant_local
+    ENGINE = Distributed('{cluster}', currentDatabase(), merchant_local, cityHash64(id));
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS reminder_local ON CLUSTER '{cluster}' SYNC;
@@ -0,0 +1,2 @@
+CREATE TABLE IF NOT EXISTS reminder ON CLUSTER '{cluster}' AS reminder_local
+    ENGINE = Distributed('{cluster}', currentDatabase(), reminder_local, cityHash64(id));
@@ -0,0 +1,2 @@
+CREATE TABLE IF NOT EXISTS reminder_marker ON CLUSTER '{cluster}' AS reminder_marker_local
+    ENGINE = Distributed('{cluster}', currentDatabase(), reminder_marker_local, cityHash64(id));
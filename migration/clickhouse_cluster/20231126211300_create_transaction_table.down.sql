@@ -0,0 +1 @@
+DROP TABLE IF EXISTS transaction_local ON CLUSTER '{cluster}' SYNC;
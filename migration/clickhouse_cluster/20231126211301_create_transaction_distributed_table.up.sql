@@ -0,0 +1,2 @@
+CREATE TABLE IF NOT EXISTS transaction ON CLUSTER '{cluster}' AS transaction_local
+    ENGINE = Distributed('{cluster}', currentDatabase(), transaction_local, cityHash64(id));
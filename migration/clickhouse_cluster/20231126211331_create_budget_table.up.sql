@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS budget_local ON CLUSTER '{cluster}'
+(
+    changed     Int32,
+    user        Int32,
+    tag         Nullable(UUID),
+    date        String,
+    income      Float64,
+    income_lock  UInt8,
+    outcome     Float64,
+    outcome_lock UInt8
+) ENGINE = ReplicatedMergeTree('/clickhouse/tables/{shard}/{database}/budget_local', '{replica}') ORDER BY date;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS budget ON CLUSTER '{cluster}' SYNC;
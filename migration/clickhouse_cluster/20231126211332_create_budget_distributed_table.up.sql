@@ -0,0 +1,2 @@
+CREATE TABLE IF NOT EXISTS budget ON CLUSTER '{cluster}' AS budget_local
+    ENGINE = Distributed('{cluster}', currentDatabase(), budget_local, rand());
@@ -0,0 +1,2 @@
+ALTER TABLE instrument ON CLUSTER '{cluster}'
+    DROP COLUMN IF EXISTS profile;
@@ -0,0 +1,2 @@
+ALTER TABLE country ON CLUSTER '{cluster}'
+    DROP COLUMN IF EXISTS profile;
@@ -0,0 +1,2 @@
+ALTER TABLE company_local ON CLUSTER '{cluster}'
+    DROP COLUMN IF EXISTS profile;
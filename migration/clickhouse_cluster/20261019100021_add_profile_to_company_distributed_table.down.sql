@@ -0,0 +1,2 @@
+ALTER TABLE company ON CLUSTER '{cluster}'
+    DROP COLUMN IF EXISTS profile;
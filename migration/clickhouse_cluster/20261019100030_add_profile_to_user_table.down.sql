@@ -0,0 +1,2 @@
+ALTER TABLE user_local ON CLUSTER '{cluster}'
+    DROP COLUMN IF EXISTS profile;
@@ -0,0 +1,2 @@
+ALTER TABLE user ON CLUSTER '{cluster}'
+    DROP COLUMN IF EXISTS profile;
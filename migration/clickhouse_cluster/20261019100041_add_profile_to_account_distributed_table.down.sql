@@ -0,0 +1,2 @@
+ALTER TABLE account ON CLUSTER '{cluster}'
+    DROP COLUMN IF EXISTS profile;
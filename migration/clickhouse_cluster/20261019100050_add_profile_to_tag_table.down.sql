@@ -0,0 +1,2 @@
+ALTER TABLE tag_local ON CLUSTER '{cluster}'
+    DROP COLUMN IF EXISTS profile;
@@ -0,0 +1,2 @@
+ALTER TABLE merchant ON CLUSTER '{cluster}'
+    DROP COLUMN IF EXISTS profile;
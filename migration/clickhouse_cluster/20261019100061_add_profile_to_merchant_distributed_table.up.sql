@@ -0,0 +1,2 @@
+ALTER TABLE merchant ON CLUSTER '{cluster}'
+    ADD COLUMN IF NOT EXISTS profile LowCardinality(String) DEFAULT 'default';
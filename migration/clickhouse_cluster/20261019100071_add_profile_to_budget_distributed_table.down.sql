@@ -0,0 +1,2 @@
+ALTER TABLE budget ON CLUSTER '{cluster}'
+    DROP COLUMN IF EXISTS profile;
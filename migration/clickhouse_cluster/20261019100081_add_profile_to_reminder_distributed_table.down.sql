@@ -0,0 +1,2 @@
+ALTER TABLE reminder ON CLUSTER '{cluster}'
+    DROP COLUMN IF EXISTS profile;
@@ -0,0 +1,2 @@
+ALTER TABLE reminder_marker ON CLUSTER '{cluster}'
+    DROP COLUMN IF EXISTS profile;
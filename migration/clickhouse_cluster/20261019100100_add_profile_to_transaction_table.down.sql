@@ -0,0 +1,2 @@
+ALTER TABLE transaction_local ON CLUSTER '{cluster}'
+    DROP COLUMN IF EXISTS profile;
@@ -0,0 +1,2 @@
+ALTER TABLE transaction ON CLUSTER '{cluster}'
+    DROP COLUMN IF EXISTS profile;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS sync_run_local ON CLUSTER '{cluster}' SYNC;
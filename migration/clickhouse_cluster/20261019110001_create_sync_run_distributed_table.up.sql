@@ -0,0 +1,2 @@
+CREATE TABLE IF NOT EXISTS sync_run ON CLUSTER '{cluster}' AS sync_run_local
+    ENGINE = Distributed('{cluster}', currentDatabase(), sync_run_local, cityHash64(profile));
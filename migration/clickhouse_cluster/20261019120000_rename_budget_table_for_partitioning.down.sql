@@ -0,0 +1 @@
+RENAME TABLE budget_local_unpartitioned TO budget_local ON CLUSTER '{cluster}';
@@ -0,0 +1 @@
+RENAME TABLE budget_local TO budget_local_unpartitioned ON CLUSTER '{cluster}';
@@ -0,0 +1,3 @@
+CREATE TABLE IF NOT EXISTS budget_local ON CLUSTER '{cluster}' AS budget_local_unpartitioned
+    ENGINE = ReplicatedMergeTree('/clickhouse/tables/{shard}/{database}/budget_local_partitioned', '{replica}')
+    PARTITION BY (profile, toYear(toDateOrZero(date))) ORDER BY date;
@@ -0,0 +1 @@
+INSERT INTO budget SELECT * FROM cluster('{cluster}', currentDatabase(), budget_local_unpartitioned);
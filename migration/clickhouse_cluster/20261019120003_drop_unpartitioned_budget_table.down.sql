@@ -0,0 +1,2 @@
+CREATE TABLE IF NOT EXISTS budget_local_unpartitioned ON CLUSTER '{cluster}' AS budget_local
+    ENGINE = ReplicatedMergeTree('/clickhouse/tables/{shard}/{database}/budget_local', '{replica}') ORDER BY date;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS budget_local_unpartitioned ON CLUSTER '{cluster}' SYNC;
@@ -0,0 +1 @@
+RENAME TABLE reminder_marker_local_unpartitioned TO reminder_marker_local ON CLUSTER '{cluster}';
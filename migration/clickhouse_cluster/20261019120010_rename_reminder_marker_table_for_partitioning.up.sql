@@ -0,0 +1 @@
+RENAME TABLE reminder_marker_local TO reminder_marker_local_unpartitioned ON CLUSTER '{cluster}';
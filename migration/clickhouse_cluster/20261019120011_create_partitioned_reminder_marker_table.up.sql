@@ -0,0 +1,3 @@
+CREATE TABLE IF NOT EXISTS reminder_marker_local ON CLUSTER '{cluster}' AS reminder_marker_local_unpartitioned
+    ENGINE = ReplicatedMergeTree('/clickhouse/tables/{shard}/{database}/reminder_marker_local_partitioned', '{replica}')
+    PARTITION BY (profile, toYear(toDateOrZero(date))) PRIMARY KEY id;
@@ -0,0 +1 @@
+INSERT INTO reminder_marker SELECT * FROM cluster('{cluster}', currentDatabase(), reminder_marker_local_unpartitioned);
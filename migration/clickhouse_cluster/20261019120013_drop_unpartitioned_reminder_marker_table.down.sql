@@ -0,0 +1,2 @@
+CREATE TABLE IF NOT EXISTS reminder_marker_local_unpartitioned ON CLUSTER '{cluster}' AS reminder_marker_local
+    ENGINE = ReplicatedMergeTree('/clickhouse/tables/{shard}/{database}/reminder_marker_local', '{replica}') PRIMARY KEY id;
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS reminder_marker_local_unpartitioned ON CLUSTER '{cluster}' SYNC;
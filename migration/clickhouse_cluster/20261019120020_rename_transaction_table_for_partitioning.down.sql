@@ -0,0 +1 @@
+RENAME TABLE transaction_local_unpartitioned TO transaction_local ON CLUSTER '{cluster}';
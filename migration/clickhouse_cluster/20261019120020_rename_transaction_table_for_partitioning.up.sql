@@ -0,0 +1 @@
+RENAME TABLE transaction_local TO transaction_local_unpartitioned ON CLUSTER '{cluster}';
@@ -0,0 +1,3 @@
+CREATE TABLE IF NOT EXISTS transaction_local ON CLUSTER '{cluster}' AS transaction_local_unpartitioned
+    ENGINE = ReplicatedMergeTree('/clickhouse/tables/{shard}/{database}/transaction_local_partitioned', '{replica}')
+    PARTITION BY (profile, toYear(toDateOrZero(date))) PRIMARY KEY id;
@@ -0,0 +1,3 @@
+-- Локальные таблицы без партиций не связаны с Distributed-таблицей, поэтому данные в них не копируются.
+-- Они будут загружены заново при следующей синхронизации.
+SELECT 1;
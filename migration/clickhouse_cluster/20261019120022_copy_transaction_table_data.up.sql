@@ -0,0 +1 @@
+INSERT INTO transaction SELECT * FROM cluster('{cluster}', currentDatabase(), transaction_local_unpartitioned);
@@ -0,0 +1,2 @@
+CREATE TABLE IF NOT EXISTS transaction_local_unpartitioned ON CLUSTER '{cluster}' AS transaction_local
+    ENGINE = ReplicatedMergeTree('/clickhouse/tables/{shard}/{database}/transaction_local', '{replica}') PRIMARY KEY id;
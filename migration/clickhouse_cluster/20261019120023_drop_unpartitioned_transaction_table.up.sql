@@ -0,0 +1 @@
+DROP TABLE IF EXISTS transaction_local_unpartitioned ON CLUSTER '{cluster}' SYNC;
@@ -0,0 +1,4 @@
+ALTER TABLE transaction ON CLUSTER '{cluster}'
+    DROP COLUMN IF EXISTS currency,
+    DROP COLUMN IF EXISTS income_normalized,
+    DROP COLUMN IF EXISTS outcome_normalized;
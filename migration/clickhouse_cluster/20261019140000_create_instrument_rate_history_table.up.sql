@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS instrument_rate_history_local ON CLUSTER '{cluster}'
+(
+    instrument  Int32,
+    rate        Float64,
+    observed_at DateTime,
+    profile     LowCardinality(String)
+) ENGINE = ReplicatedMergeTree('/clickhouse/tables/{shard}/{database}/instrument_rate_history_local', '{replica}')
+    PARTITION BY (profile, toYYYYMM(observed_at)) ORDER BY (profile, instrument, observed_at);
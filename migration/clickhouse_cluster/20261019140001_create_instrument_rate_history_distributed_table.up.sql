@@ -0,0 +1,2 @@
+CREATE TABLE IF NOT EXISTS instrument_rate_history ON CLUSTER '{cluster}' AS instrument_rate_history_local
+    ENGINE = Distributed('{cluster}', currentDatabase(), instrument_rate_history_local, cityHash64(profile));
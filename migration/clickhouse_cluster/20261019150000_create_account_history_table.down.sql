@@ -0,0 +1 @@
+DROP TABLE IF EXISTS account_history_local ON CLUSTER '{cluster}' SYNC;
@@ -0,0 +1,2 @@
+CREATE TABLE IF NOT EXISTS account_history ON CLUSTER '{cluster}' AS account_history_local
+    ENGINE = Distributed('{cluster}', currentDatabase(), account_history_local, cityHash64(id));
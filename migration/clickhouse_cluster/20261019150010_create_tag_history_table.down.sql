@@ -0,0 +1 @@
+DROP TABLE IF EXISTS tag_history_local ON CLUSTER '{cluster}' SYNC;
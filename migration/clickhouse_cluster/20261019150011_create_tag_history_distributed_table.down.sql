@@ -0,0 +1 @@
+DROP TABLE IF EXISTS tag_history ON CLUSTER '{cluster}' SYNC;
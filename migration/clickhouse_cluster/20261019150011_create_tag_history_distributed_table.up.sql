@@ -0,0 +1,2 @@
+CREATE TABLE IF NOT EXISTS tag_history ON CLUSTER '{cluster}' AS tag_history_local
+    ENGINE = Distributed('{cluster}', currentDatabase(), tag_history_local, cityHash64(id));
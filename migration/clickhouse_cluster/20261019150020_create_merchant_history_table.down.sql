@@ -0,0 +1 @@
+DROP TABLE IF EXISTS merchant_history_local ON CLUSTER '{cluster}' SYNC;
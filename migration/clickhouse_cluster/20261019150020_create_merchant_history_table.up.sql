@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS merchant_history_local ON CLUSTER '{cluster}'
+(
+    id         UUID,
+    changed    Int32,
+    user       Int32,
+    title      String,
+    profile    LowCardinality(String),
+    valid_from DateTime,
+    valid_to   Nullable(DateTime)
+) ENGINE = ReplicatedMergeTree('/clickhouse/tables/{shard}/{database}/merchant_history_local', '{replica}')
+    ORDER BY (profile, id, valid_from);
@@ -0,0 +1,2 @@
+CREATE TABLE IF NOT EXISTS merchant_history ON CLUSTER '{cluster}' AS merchant_history_local
+    ENGINE = Distributed('{cluster}', currentDatabase(), merchant_history_local, cityHash64(id));
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS transaction_audit_local ON CLUSTER '{cluster}'
+(
+    transaction_id UUID,
+    change_type    LowCardinality(String),
+    field          LowCardinality(String),
+    old_value      String,
+    new_value      Nullable(String),
+    changed_at     DateTime,
+    observed_at    DateTime,
+    profile        LowCardinality(String)
+) ENGINE = ReplicatedMergeTree('/clickhouse/tables/{shard}/{database}/transaction_audit_local', '{replica}')
+    PARTITION BY (profile, toYYYYMM(observed_at)) ORDER BY (profile, transaction_id, changed_at);
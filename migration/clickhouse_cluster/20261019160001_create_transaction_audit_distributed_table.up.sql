@@ -0,0 +1,2 @@
+CREATE TABLE IF NOT EXISTS transaction_audit ON CLUSTER '{cluster}' AS transaction_audit_local
+    ENGINE = Distributed('{cluster}', currentDatabase(), transaction_audit_local, cityHash64(transaction_id));
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS account_balance_daily_local ON CLUSTER '{cluster}' SYNC;
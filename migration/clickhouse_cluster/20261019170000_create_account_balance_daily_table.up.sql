@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS account_balance_daily_local ON CLUSTER '{cluster}'
+(
+    account    UUID,
+    user       Int32,
+    instrument Nullable(Int32),
+    date       Date,
+    income     Float64,
+    outcome    Float64,
+    balance    Float64,
+    profile    LowCardinality(String)
+) ENGINE = ReplicatedMergeTree('/clickhouse/tables/{shard}/{database}/account_balance_daily_local', '{replica}')
+    PARTITION BY profile ORDER BY (profile, account, date);
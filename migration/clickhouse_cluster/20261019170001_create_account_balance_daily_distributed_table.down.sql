@@ -0,0 +1 @@
+DROP TABLE IF EXISTS account_balance_daily ON CLUSTER '{cluster}' SYNC;
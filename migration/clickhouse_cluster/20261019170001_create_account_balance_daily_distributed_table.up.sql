@@ -0,0 +1,2 @@
+CREATE TABLE IF NOT EXISTS account_balance_daily ON CLUSTER '{cluster}' AS account_balance_daily_local
+    ENGINE = Distributed('{cluster}', currentDatabase(), account_balance_daily_local, cityHash64(account));
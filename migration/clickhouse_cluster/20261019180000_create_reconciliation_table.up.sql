@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS reconciliation_local ON CLUSTER '{cluster}'
+(
+    account    UUID,
+    title      String,
+    instrument Nullable(Int32),
+    balance    Float64,
+    computed   Float64,
+    difference Float64,
+    tolerance  Float64,
+    status     LowCardinality(String),
+    checked_at DateTime,
+    profile    LowCardinality(String)
+) ENGINE = ReplicatedMergeTree('/clickhouse/tables/{shard}/{database}/reconciliation_local', '{replica}')
+    PARTITION BY profile ORDER BY (profile, account);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS reconciliation ON CLUSTER '{cluster}' SYNC;
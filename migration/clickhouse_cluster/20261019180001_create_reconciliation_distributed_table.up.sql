@@ -0,0 +1,2 @@
+CREATE TABLE IF NOT EXISTS reconciliation ON CLUSTER '{cluster}' AS reconciliation_local
+    ENGINE = Distributed('{cluster}', currentDatabase(), reconciliation_local, cityHash64(account));
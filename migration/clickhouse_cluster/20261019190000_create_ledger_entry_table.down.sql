@@ -0,0 +1 @@
+DROP TABLE IF EXISTS ledger_entry_local ON CLUSTER '{cluster}' SYNC;
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS ledger_entry_local ON CLUSTER '{cluster}'
+(
+    transaction_id UUID,
+    account        UUID,
+    instrument     Int32,
+    user           Int32,
+    date           Date,
+    kind           LowCardinality(String),
+    amount         Float64,
+    tag            Nullable(UUID),
+    merchant       Nullable(UUID),
+    payee          String,
+    profile        LowCardinality(String)
+) ENGINE = ReplicatedMergeTree('/clickhouse/tables/{shard}/{database}/ledger_entry_local', '{replica}')
+    PARTITION BY profile ORDER BY (profile, date, account, transaction_id);
@@ -0,0 +1,2 @@
+CREATE TABLE IF NOT EXISTS ledger_entry ON CLUSTER '{cluster}' AS ledger_entry_local
+    ENGINE = Distributed('{cluster}', currentDatabase(), ledger_entry_local, cityHash64(transaction_id));
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS tag_path_local ON CLUSTER '{cluster}'
+(
+    tag               UUID,
+    user              Int32,
+    title             String,
+    root              UUID,
+    root_title        String,
+    subcategory       Nullable(UUID),
+    subcategory_title Nullable(String),
+    path              String,
+    depth             UInt8,
+    profile           LowCardinality(String)
+) ENGINE = ReplicatedMergeTree('/clickhouse/tables/{shard}/{database}/tag_path_local', '{replica}')
+    PARTITION BY profile ORDER BY (profile, tag);
@@ -0,0 +1,2 @@
+CREATE TABLE IF NOT EXISTS tag_path ON CLUSTER '{cluster}' AS tag_path_local
+    ENGINE = Distributed('{cluster}', currentDatabase(), tag_path_local, cityHash64(tag));
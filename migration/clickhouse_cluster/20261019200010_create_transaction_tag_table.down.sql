@@ -0,0 +1 @@
+DROP TABLE IF EXISTS transaction_tag_local ON CLUSTER '{cluster}' SYNC;
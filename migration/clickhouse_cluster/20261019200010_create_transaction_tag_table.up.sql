@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS transaction_tag_local ON CLUSTER '{cluster}'
+(
+    transaction_id UUID,
+    tag            UUID,
+    position       UInt8,
+    is_primary     BOOL,
+    profile        LowCardinality(String)
+) ENGINE = ReplicatedMergeTree('/clickhouse/tables/{shard}/{database}/transaction_tag_local', '{replica}')
+    PARTITION BY profile ORDER BY (profile, tag, transaction_id);
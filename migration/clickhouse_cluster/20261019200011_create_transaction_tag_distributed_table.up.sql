@@ -0,0 +1,2 @@
+CREATE TABLE IF NOT EXISTS transaction_tag ON CLUSTER '{cluster}' AS transaction_tag_local
+    ENGINE = Distributed('{cluster}', currentDatabase(), transaction_tag_local, cityHash64(transaction_id));
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS forecast_transaction_local ON CLUSTER '{cluster}'
+(
+    reminder           UUID,
+    user               Int32,
+    date               Date,
+    income_instrument  Int32,
+    income_account     String,
+    income             Float64,
+    outcome_instrument Int32,
+    outcome_account    String,
+    outcome            Float64,
+    tag                Array(UUID),
+    merchant           Nullable(UUID),
+    payee              String,
+    comment            String,
+    reminder_marker    Nullable(UUID),
+    marker_state       Nullable(String),
+    profile            LowCardinality(String)
+) ENGINE = ReplicatedMergeTree('/clickhouse/tables/{shard}/{database}/forecast_transaction_local', '{replica}')
+    PARTITION BY profile ORDER BY (profile, date, reminder);
@@ -0,0 +1,2 @@
+CREATE TABLE IF NOT EXISTS forecast_transaction ON CLUSTER '{cluster}' AS forecast_transaction_local
+    ENGINE = Distributed('{cluster}', currentDatabase(), forecast_transaction_local, cityHash64(reminder));
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS budget_actual_local ON CLUSTER '{cluster}'
+(
+    user             Int32,
+    tag              Nullable(UUID),
+    month            Date,
+    planned_income   Float64,
+    actual_income    Float64,
+    income_variance  Float64,
+    planned_outcome  Float64,
+    actual_outcome   Float64,
+    outcome_variance Float64,
+    profile          LowCardinality(String)
+) ENGINE = ReplicatedMergeTree('/clickhouse/tables/{shard}/{database}/budget_actual_local', '{replica}')
+    PARTITION BY profile ORDER BY (profile, user, month);
@@ -0,0 +1,2 @@
+CREATE TABLE IF NOT EXISTS budget_actual ON CLUSTER '{cluster}' AS budget_actual_local
+    ENGINE = Distributed('{cluster}', currentDatabase(), budget_actual_local, cityHash64(user));
@@ -0,0 +1,10 @@
+// Package migration содержит SQL-миграции, которые встраиваются в бинарный файл и применяются командой migrate.
+// Каждый файл содержит один запрос, а имя файла включает имя сущности, к таблице которой он относится.
+package migration
+
+import "embed"
+
+// FS содержит миграции ClickHouse: обычные в каталоге clickhouse и кластерные в каталоге clickhouse_cluster.
+//
+//go:embed clickhouse/*.sql clickhouse_cluster/*.sql
+var FS embed.FS